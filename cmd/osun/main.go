@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
 
+	"github.com/intellidevelopers/osun-lang/internal/diag"
 	"github.com/intellidevelopers/osun-lang/internal/interpreter"
 	"github.com/intellidevelopers/osun-lang/internal/runtime"
 )
@@ -13,16 +16,59 @@ func main() {
 	// Initialize built-in functions and objects
 	runtime.InitBuiltins()
 
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <file.os>")
+	jsonDiagnostics := flag.Bool("json", false, "write errors to stderr as JSON diagnostics, one per line")
+	timeout := flag.Duration("timeout", 0, "stop the script's top level after this long (0 = no limit)")
+	requestTimeout := flag.Duration("request-timeout", 0, "stop a script HTTP handler after this long (0 = no limit)")
+	maxSteps := flag.Int("max-steps", 0, "stop a run or request after this many statements (0 = no limit)")
+	watch := flag.Bool("watch", false, "run the script again whenever a .os file under the working directory changes")
+	flag.Parse()
+	if flag.NArg() < 1 {
+		fmt.Println("Usage: osun [-json] [-watch] [-timeout d] [-request-timeout d] [-max-steps n] <file.os>")
 		return
 	}
+	opts := interpreter.Options{
+		Timeout:        *timeout,
+		RequestTimeout: *requestTimeout,
+		MaxSteps:       *maxSteps,
+	}
+	if *jsonDiagnostics {
+		opts.Diagnostics = &diag.Printer{Out: os.Stderr, Format: diag.JSON}
+	}
+
+	file := flag.Arg(0)
+	interp := interpreter.New(opts)
+	if *watch {
+		runAndWatch(interp, file)
+		return
+	}
+	runAndMaybeStartServer(interp, file)
+}
 
-	file := os.Args[1]
-	runAndMaybeStartServer(file)
+// runAndWatch runs the file, then runs it again after every change. Only
+// the files that changed are parsed and compiled again.
+//
+// A script that starts a server blocks in Listen, so the first run goes on
+// in the background while the files are watched. All runs share a reload
+// scope, so later runs listening on the port the first one serves swap in
+// their routes and return.
+func runAndWatch(interp *interpreter.Interpreter, file string) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		fmt.Println("Failed to read file:", err)
+		return
+	}
+	ctx := runtime.ReloadScope(context.Background())
+	interp.SetVariable("server", runtime.NewOsunServer(8080))
+	go interp.RunFileContext(ctx, file, string(data))
+	runtime.WatchAndRun(file, func(code string, changed []string) {
+		// A fresh server, so routes deleted from the script go away.
+		interp.SetVariable("server", runtime.NewOsunServer(8080))
+		interp.Invalidate(changed...)
+		interp.RunFileContext(ctx, file, code)
+	})
 }
 
-func runAndMaybeStartServer(file string) {
+func runAndMaybeStartServer(interp *interpreter.Interpreter, file string) {
 	data, err := os.ReadFile(filepath.Clean(file))
 	if err != nil {
 		fmt.Println("Failed to read file:", err)
@@ -31,10 +77,10 @@ func runAndMaybeStartServer(file string) {
 
 	// Create a server and store it in interpreter variables
 	server := runtime.NewOsunServer(8080) // default port, change if needed
-	interpreter.SetVariable("server", server)
+	interp.SetVariable("server", server)
 
 	// Run the .os code
-	interpreter.Run(string(data))
+	interp.RunFile(file, string(data))
 
 	// If the code created any handlers and called server.Listen(), it will run
 	fmt.Println("Server started on port 8080. Press Ctrl+C to stop.")
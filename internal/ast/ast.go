@@ -0,0 +1,324 @@
+package ast
+
+import "github.com/intellidevelopers/osun-lang/internal/token"
+
+// Node is implemented by every AST node.
+type Node interface {
+	Pos() token.Pos
+}
+
+// Statement is a node that can appear in a block.
+type Statement interface {
+	Node
+	statementNode()
+}
+
+// Expression is a node that produces a value.
+type Expression interface {
+	Node
+	expressionNode()
+}
+
+// Program is the root of a parsed .os file.
+type Program struct {
+	Statements []Statement
+}
+
+func (p *Program) Pos() token.Pos {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Pos{Line: 1, Col: 1}
+}
+
+// -------------------- Statements ------------------------
+
+// BlockStatement is a brace-delimited list of statements.
+type BlockStatement struct {
+	LBrace     token.Pos
+	Statements []Statement
+}
+
+// LetStatement binds a new variable: let name = value, or const name = value
+type LetStatement struct {
+	Let   token.Pos
+	Const bool
+	Name  *Identifier
+	Value Expression
+}
+
+// IfStatement is: if cond { ... } else { ... }
+type IfStatement struct {
+	If          token.Pos
+	Condition   Expression
+	Consequence *BlockStatement
+	Alternative Statement // *BlockStatement, *IfStatement for else-if, or nil
+}
+
+// WhileStatement is: while cond { ... }
+type WhileStatement struct {
+	While     token.Pos
+	Condition Expression
+	Body      *BlockStatement
+}
+
+// ForInStatement is: for value in iterable { ... } or
+// for key, value in iterable { ... }
+type ForInStatement struct {
+	For      token.Pos
+	Key      *Identifier // nil in the single-variable form
+	Value    *Identifier
+	Iterable Expression // may be a *RangeExpression
+	Body     *BlockStatement
+}
+
+// BreakStatement exits the innermost loop.
+type BreakStatement struct {
+	Break token.Pos
+}
+
+// ContinueStatement skips to the next iteration of the innermost loop.
+type ContinueStatement struct {
+	Continue token.Pos
+}
+
+// FunctionStatement declares a named function: fn name(params) { ... }
+type FunctionStatement struct {
+	Name *Identifier
+	Func *FunctionLiteral
+}
+
+// ReturnStatement is: return [value]
+type ReturnStatement struct {
+	Return token.Pos
+	Value  Expression // nil for a bare return
+}
+
+// TryStatement is: try { ... } catch (e) { ... } finally { ... }
+// At least one of Catch and Finally is set.
+type TryStatement struct {
+	Try        token.Pos
+	Body       *BlockStatement
+	CatchParam *Identifier     // nil for a bare catch
+	Catch      *BlockStatement // nil without a catch clause
+	Finally    *BlockStatement // nil without a finally clause
+}
+
+// ThrowStatement is: throw value
+type ThrowStatement struct {
+	Throw token.Pos
+	Value Expression
+}
+
+// ImportStatement is: import { name, other as alias } from "path", or
+// import "path" to run a module only for its side effects.
+type ImportStatement struct {
+	Import token.Pos
+	Names  []*ImportName
+	Path   *StringLiteral
+}
+
+// ImportName is one imported binding. Alias is nil when the binding keeps
+// its exported name.
+type ImportName struct {
+	Name  *Identifier
+	Alias *Identifier
+}
+
+// ExportStatement is: export let|const|fn declaration
+type ExportStatement struct {
+	Export token.Pos
+	Decl   Statement // *LetStatement or *FunctionStatement
+}
+
+// AssignStatement updates an existing variable, element or field:
+// name = value, xs[i] += value, obj.field = value
+type AssignStatement struct {
+	Target Expression // *Identifier, *IndexExpression or *MemberExpression
+	OpPos  token.Pos
+	Op     token.Kind // ASSIGN or a compound operator such as PLUS_ASSIGN
+	Value  Expression
+}
+
+// IncDecStatement is: target++ or target--
+type IncDecStatement struct {
+	Target Expression
+	OpPos  token.Pos
+	Op     token.Kind // INC or DEC
+}
+
+// ExpressionStatement is an expression evaluated for its side effects.
+type ExpressionStatement struct {
+	Expr Expression
+}
+
+func (s *BlockStatement) Pos() token.Pos      { return s.LBrace }
+func (s *LetStatement) Pos() token.Pos        { return s.Let }
+func (s *IfStatement) Pos() token.Pos         { return s.If }
+func (s *WhileStatement) Pos() token.Pos      { return s.While }
+func (s *ForInStatement) Pos() token.Pos      { return s.For }
+func (s *BreakStatement) Pos() token.Pos      { return s.Break }
+func (s *ContinueStatement) Pos() token.Pos   { return s.Continue }
+func (s *FunctionStatement) Pos() token.Pos   { return s.Func.Fn }
+func (s *ReturnStatement) Pos() token.Pos     { return s.Return }
+func (s *TryStatement) Pos() token.Pos        { return s.Try }
+func (s *ThrowStatement) Pos() token.Pos      { return s.Throw }
+func (s *ImportStatement) Pos() token.Pos     { return s.Import }
+func (s *ExportStatement) Pos() token.Pos     { return s.Export }
+func (s *AssignStatement) Pos() token.Pos     { return s.Target.Pos() }
+func (s *IncDecStatement) Pos() token.Pos     { return s.Target.Pos() }
+func (s *ExpressionStatement) Pos() token.Pos { return s.Expr.Pos() }
+
+func (*BlockStatement) statementNode()      {}
+func (*LetStatement) statementNode()        {}
+func (*IfStatement) statementNode()         {}
+func (*WhileStatement) statementNode()      {}
+func (*ForInStatement) statementNode()      {}
+func (*BreakStatement) statementNode()      {}
+func (*ContinueStatement) statementNode()   {}
+func (*FunctionStatement) statementNode()   {}
+func (*ReturnStatement) statementNode()     {}
+func (*TryStatement) statementNode()        {}
+func (*ThrowStatement) statementNode()      {}
+func (*ImportStatement) statementNode()     {}
+func (*ExportStatement) statementNode()     {}
+func (*AssignStatement) statementNode()     {}
+func (*IncDecStatement) statementNode()     {}
+func (*ExpressionStatement) statementNode() {}
+
+// -------------------- Expressions ------------------------
+
+// Identifier is a reference to a variable or builtin symbol.
+type Identifier struct {
+	NamePos token.Pos
+	Name    string
+}
+
+// NumberLiteral is a numeric constant.
+type NumberLiteral struct {
+	ValuePos token.Pos
+	Raw      string
+	Value    any // int64 for integer literals, float64 otherwise
+}
+
+// StringLiteral is a quoted string constant with quotes removed.
+type StringLiteral struct {
+	ValuePos token.Pos
+	Value    string
+}
+
+// TemplateLiteral is a backtick string: `Hello ${name}!`. Text parts are
+// *StringLiteral; the rest are interpolated expressions.
+type TemplateLiteral struct {
+	Backtick token.Pos
+	Parts    []Expression
+}
+
+// BooleanLiteral is true or false.
+type BooleanLiteral struct {
+	ValuePos token.Pos
+	Value    bool
+}
+
+// NullLiteral is the null constant.
+type NullLiteral struct {
+	ValuePos token.Pos
+}
+
+// ArrayLiteral is: [a, b, c]
+type ArrayLiteral struct {
+	Lbrack   token.Pos
+	Elements []Expression
+}
+
+// ObjectLiteral is: { key: value, "other key": value }
+type ObjectLiteral struct {
+	Lbrace token.Pos
+	Keys   []string
+	Values []Expression
+}
+
+// BinaryExpression is: left op right. For && and || the right operand is
+// only evaluated when needed.
+type BinaryExpression struct {
+	OpPos token.Pos
+	Op    token.Kind
+	Left  Expression
+	Right Expression
+}
+
+// UnaryExpression is: op operand
+type UnaryExpression struct {
+	OpPos   token.Pos
+	Op      token.Kind
+	Operand Expression
+}
+
+// RangeExpression is: start..end, the half-open integer range [start, end).
+// It only appears as the iterable of a for-in loop.
+type RangeExpression struct {
+	OpPos token.Pos
+	Start Expression
+	End   Expression
+}
+
+// IndexExpression is: object[index]
+type IndexExpression struct {
+	Lbrack token.Pos
+	Object Expression
+	Index  Expression
+}
+
+// FunctionLiteral is: fn [name](params) { body }
+type FunctionLiteral struct {
+	Fn     token.Pos
+	Name   string // empty for anonymous functions
+	Params []*Identifier
+	Body   *BlockStatement
+}
+
+// CallExpression is: callee(args...)
+type CallExpression struct {
+	Lparen token.Pos
+	Callee Expression
+	Args   []Expression
+}
+
+// MemberExpression is: object.property
+type MemberExpression struct {
+	Dot      token.Pos
+	Object   Expression
+	Property *Identifier
+}
+
+func (e *Identifier) Pos() token.Pos       { return e.NamePos }
+func (e *NumberLiteral) Pos() token.Pos    { return e.ValuePos }
+func (e *StringLiteral) Pos() token.Pos    { return e.ValuePos }
+func (e *TemplateLiteral) Pos() token.Pos  { return e.Backtick }
+func (e *BooleanLiteral) Pos() token.Pos   { return e.ValuePos }
+func (e *NullLiteral) Pos() token.Pos      { return e.ValuePos }
+func (e *ArrayLiteral) Pos() token.Pos     { return e.Lbrack }
+func (e *ObjectLiteral) Pos() token.Pos    { return e.Lbrace }
+func (e *BinaryExpression) Pos() token.Pos { return e.Left.Pos() }
+func (e *UnaryExpression) Pos() token.Pos  { return e.OpPos }
+func (e *RangeExpression) Pos() token.Pos  { return e.Start.Pos() }
+func (e *IndexExpression) Pos() token.Pos  { return e.Object.Pos() }
+func (e *FunctionLiteral) Pos() token.Pos  { return e.Fn }
+func (e *CallExpression) Pos() token.Pos   { return e.Callee.Pos() }
+func (e *MemberExpression) Pos() token.Pos { return e.Object.Pos() }
+
+func (*Identifier) expressionNode()       {}
+func (*NumberLiteral) expressionNode()    {}
+func (*StringLiteral) expressionNode()    {}
+func (*TemplateLiteral) expressionNode()  {}
+func (*BooleanLiteral) expressionNode()   {}
+func (*NullLiteral) expressionNode()      {}
+func (*ArrayLiteral) expressionNode()     {}
+func (*ObjectLiteral) expressionNode()    {}
+func (*BinaryExpression) expressionNode() {}
+func (*UnaryExpression) expressionNode()  {}
+func (*RangeExpression) expressionNode()  {}
+func (*IndexExpression) expressionNode()  {}
+func (*FunctionLiteral) expressionNode()  {}
+func (*CallExpression) expressionNode()   {}
+func (*MemberExpression) expressionNode() {}
@@ -0,0 +1,154 @@
+// Package diag formats syntax and runtime errors for people and for
+// editors: file:line:col, the offending source line with a caret, or one
+// JSON object per diagnostic.
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind says which stage of execution produced a diagnostic.
+type Kind string
+
+const (
+	Syntax  Kind = "syntax"
+	Runtime Kind = "runtime"
+)
+
+// Diagnostic is a single error tied to a source location. Line and Column
+// are 1-based; zero means the location is unknown.
+type Diagnostic struct {
+	Kind    Kind    `json:"kind"`
+	Type    string  `json:"type,omitempty"` // runtime error kind such as TypeError
+	File    string  `json:"file"`
+	Line    int     `json:"line"`
+	Column  int     `json:"column"`
+	Message string  `json:"message"`
+	Stack   []Frame `json:"stack,omitempty"` // innermost call first
+}
+
+// Frame is one entry of a runtime stack trace: the function that was
+// executing and where. Entry points outside the script, such as an HTTP
+// request, have no location.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+}
+
+func (f Frame) String() string {
+	if f.Line == 0 {
+		return "at " + f.Function
+	}
+	loc := fmt.Sprintf("%d:%d", f.Line, f.Column)
+	if f.File != "" {
+		loc = f.File + ":" + loc
+	}
+	return fmt.Sprintf("at %s (%s)", f.Function, loc)
+}
+
+func (d *Diagnostic) String() string {
+	var b strings.Builder
+	if d.File != "" {
+		b.WriteString(d.File + ":")
+	}
+	if d.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", d.Line, d.Column)
+	}
+	if b.Len() > 0 {
+		b.WriteString(" ")
+	}
+	fmt.Fprintf(&b, "%s error: ", d.Kind)
+	if d.Type != "" {
+		b.WriteString(d.Type + ": ")
+	}
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// Source is the text of a script, split into lines for snippets.
+type Source struct {
+	Name  string
+	lines []string
+}
+
+func NewSource(name, text string) *Source {
+	return &Source{Name: name, lines: strings.Split(text, "\n")}
+}
+
+// Line returns the 1-based line n without its line ending.
+func (s *Source) Line(n int) (string, bool) {
+	if s == nil || n < 1 || n > len(s.lines) {
+		return "", false
+	}
+	return strings.TrimRight(s.lines[n-1], "\r"), true
+}
+
+// -------------------- Printing ------------------------
+
+// Format selects how a Printer writes diagnostics.
+type Format int
+
+const (
+	Text Format = iota // human readable, with a source snippet
+	JSON               // one JSON object per line
+)
+
+// Printer writes diagnostics to Out in the chosen Format.
+type Printer struct {
+	Out    io.Writer
+	Format Format
+}
+
+// Print writes d, using src for the source snippet when it is available.
+func (p *Printer) Print(src *Source, d *Diagnostic) {
+	if p.Format == JSON {
+		data, _ := json.Marshal(d)
+		fmt.Fprintln(p.Out, string(data))
+		return
+	}
+	fmt.Fprintln(p.Out, "❌", d.String())
+	if line, ok := src.Line(d.Line); ok {
+		gutter := fmt.Sprintf("%4d | ", d.Line)
+		fmt.Fprintf(p.Out, "%s%s\n", gutter, line)
+		fmt.Fprintf(p.Out, "%*s| %s^\n", len(gutter)-2, "", caretPadding(line, d.Column))
+	}
+	p.printStack(d.Stack)
+}
+
+// maxFrames is how many frames a text trace shows before eliding the
+// middle of a deep recursion.
+const maxFrames = 20
+
+func (p *Printer) printStack(stack []Frame) {
+	for i := 0; i < len(stack); i++ {
+		if len(stack) > maxFrames && i == maxFrames/2 {
+			skip := len(stack) - maxFrames
+			fmt.Fprintf(p.Out, "    ... %d more frames\n", skip)
+			i += skip - 1
+			continue
+		}
+		fmt.Fprintln(p.Out, "   ", stack[i])
+	}
+}
+
+// caretPadding returns the whitespace that lines a caret up under column
+// col of line, keeping tabs so the caret lands in the same place.
+func caretPadding(line string, col int) string {
+	var b strings.Builder
+	for i, r := range []rune(line) {
+		if i >= col-1 {
+			break
+		}
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,95 @@
+package diag
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONShape(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Diagnostic
+		want string
+	}{
+		{
+			"syntax",
+			Diagnostic{Kind: Syntax, File: "a.os", Line: 2, Column: 5, Message: "unexpected )"},
+			`{"kind":"syntax","file":"a.os","line":2,"column":5,"message":"unexpected )"}`,
+		},
+		{
+			"runtime",
+			Diagnostic{Kind: Runtime, Type: "TypeError", File: "a.os", Line: 3, Column: 1, Message: "bad"},
+			`{"kind":"runtime","type":"TypeError","file":"a.os","line":3,"column":1,"message":"bad"}`,
+		},
+		{
+			"runtime with stack",
+			Diagnostic{
+				Kind: Runtime, Type: "TypeError", File: "a.os", Line: 3, Column: 1, Message: "bad",
+				Stack: []Frame{
+					{Function: "f", File: "a.os", Line: 3, Column: 1},
+					{Function: "GET /users"},
+				},
+			},
+			`{"kind":"runtime","type":"TypeError","file":"a.os","line":3,"column":1,"message":"bad",` +
+				`"stack":[{"function":"f","file":"a.os","line":3,"column":1},{"function":"GET /users"}]}`,
+		},
+		{
+			"unknown location",
+			Diagnostic{Kind: Runtime, Message: "boom"},
+			`{"kind":"runtime","file":"","line":0,"column":0,"message":"boom"}`,
+		},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		p := &Printer{Out: &out, Format: JSON}
+		p.Print(nil, &tt.d)
+		if got := out.String(); got != tt.want+"\n" {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, tt.want)
+		}
+		var back Diagnostic
+		if err := json.Unmarshal([]byte(out.String()), &back); err != nil {
+			t.Errorf("%s: output is not valid JSON: %v", tt.name, err)
+		}
+	}
+}
+
+func TestTextSnippet(t *testing.T) {
+	src := NewSource("a.os", "let x = 1\n\tprint(y)\r\n")
+	d := &Diagnostic{Kind: Runtime, Type: "ReferenceError", File: "a.os", Line: 2, Column: 8, Message: "undefined variable y"}
+	var out strings.Builder
+	(&Printer{Out: &out}).Print(src, d)
+	want := "❌ a.os:2:8: runtime error: ReferenceError: undefined variable y\n" +
+		"   2 | \tprint(y)\n" +
+		"     | \t      ^\n"
+	if out.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestTextNoLocation(t *testing.T) {
+	var out strings.Builder
+	(&Printer{Out: &out}).Print(nil, &Diagnostic{Kind: Syntax, Message: "empty"})
+	if got, want := out.String(), "❌ syntax error: empty\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestStackElision(t *testing.T) {
+	stack := make([]Frame, 25)
+	for i := range stack {
+		stack[i] = Frame{Function: "f", Line: i + 1, Column: 1}
+	}
+	var out strings.Builder
+	(&Printer{Out: &out}).Print(nil, &Diagnostic{Kind: Runtime, Message: "deep", Stack: stack})
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")[1:]
+	if len(lines) != maxFrames+1 {
+		t.Fatalf("got %d trace lines, want %d:\n%s", len(lines), maxFrames+1, out.String())
+	}
+	if lines[10] != "    ... 5 more frames" {
+		t.Errorf("elision line = %q", lines[10])
+	}
+	if lines[9] != "    at f (10:1)" || lines[11] != "    at f (16:1)" {
+		t.Errorf("frames around the elision: %q, %q", lines[9], lines[11])
+	}
+}
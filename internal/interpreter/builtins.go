@@ -0,0 +1,299 @@
+package interpreter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Builtin is a function implemented in Go that operates directly on Osun
+// values, as opposed to the reflective runtime symbols.
+type Builtin struct {
+	Name string
+	Fn   func(args []any) (any, error)
+
+	// host marks a builtin added with Register. Like other Go code it runs
+	// with the interpreter unlocked, so it may block or call back into
+	// scripts; the standard builtins run locked.
+	host bool
+}
+
+// Call invokes the builtin, letting it be used wherever a runtime.Callable is.
+func (b *Builtin) Call(args ...any) (any, error) {
+	return b.Fn(args)
+}
+
+func (b *Builtin) String() string {
+	return fmt.Sprintf("<builtin %s>", b.Name)
+}
+
+// defaultBuiltins returns a fresh set of the standard builtins, which each
+// Interpreter can then extend with Register.
+func defaultBuiltins() map[string]*Builtin {
+	builtins := map[string]*Builtin{}
+	for _, b := range []*Builtin{
+		{Name: "len", Fn: builtinLen},
+		{Name: "push", Fn: builtinPush},
+		{Name: "pop", Fn: builtinPop},
+		{Name: "slice", Fn: builtinSlice},
+		{Name: "contains", Fn: builtinContains},
+		{Name: "join", Fn: builtinJoin},
+		{Name: "error", Fn: builtinError},
+		{Name: "int", Fn: builtinInt},
+		{Name: "float", Fn: builtinFloat},
+		{Name: "str", Fn: builtinStr},
+	} {
+		builtins[b.Name] = b
+	}
+	return builtins
+}
+
+func checkArgs(name string, args []any, min, max int) error {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return fmt.Errorf("%s expects %d arguments, got %d", name, min, len(args))
+		}
+		return fmt.Errorf("%s expects %d to %d arguments, got %d", name, min, max, len(args))
+	}
+	return nil
+}
+
+func arrayArg(name string, v any) (*Array, error) {
+	arr, ok := v.(*Array)
+	if !ok {
+		return nil, fmt.Errorf("%s expects an array, got %s", name, typeName(v))
+	}
+	return arr, nil
+}
+
+// -------------------- List builtins ------------------------
+
+func builtinLen(args []any) (any, error) {
+	if err := checkArgs("len", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case *Array:
+		return int64(len(v.Elements)), nil
+	case *Object:
+		return int64(v.Len()), nil
+	case string:
+		return int64(utf8.RuneCountInString(v)), nil
+	}
+	return nil, fmt.Errorf("len: unsupported type %s", typeName(args[0]))
+}
+
+// builtinPush appends values to an array in place and returns its new length.
+func builtinPush(args []any) (any, error) {
+	if err := checkArgs("push", args, 2, 1<<30); err != nil {
+		return nil, err
+	}
+	arr, err := arrayArg("push", args[0])
+	if err != nil {
+		return nil, err
+	}
+	arr.Elements = append(arr.Elements, args[1:]...)
+	return int64(len(arr.Elements)), nil
+}
+
+// builtinPop removes and returns the last element of an array.
+func builtinPop(args []any) (any, error) {
+	if err := checkArgs("pop", args, 1, 1); err != nil {
+		return nil, err
+	}
+	arr, err := arrayArg("pop", args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(arr.Elements) == 0 {
+		return nil, fmt.Errorf("pop from empty array")
+	}
+	last := arr.Elements[len(arr.Elements)-1]
+	arr.Elements = arr.Elements[:len(arr.Elements)-1]
+	return last, nil
+}
+
+// builtinSlice returns a copy of xs[start:end]; end defaults to len(xs).
+func builtinSlice(args []any) (any, error) {
+	if err := checkArgs("slice", args, 2, 3); err != nil {
+		return nil, err
+	}
+	var length int
+	switch v := args[0].(type) {
+	case *Array:
+		length = len(v.Elements)
+	case string:
+		length = utf8.RuneCountInString(v)
+	default:
+		return nil, fmt.Errorf("slice: unsupported type %s", typeName(args[0]))
+	}
+
+	start, err := sliceBound("start", args[1], length)
+	if err != nil {
+		return nil, err
+	}
+	end := length
+	if len(args) == 3 {
+		if end, err = sliceBound("end", args[2], length); err != nil {
+			return nil, err
+		}
+	}
+	if start > end {
+		return nil, fmt.Errorf("slice start %d is after end %d", start, end)
+	}
+
+	if arr, ok := args[0].(*Array); ok {
+		out := make([]any, end-start)
+		copy(out, arr.Elements[start:end])
+		return NewArray(out...), nil
+	}
+	return string([]rune(args[0].(string))[start:end]), nil
+}
+
+func sliceBound(which string, v any, length int) (int, error) {
+	n, err := toInt(v)
+	if err != nil {
+		return 0, fmt.Errorf("slice %s: %v", which, err)
+	}
+	if n < 0 || n > length {
+		return 0, fmt.Errorf("slice %s %d out of range for length %d", which, n, length)
+	}
+	return n, nil
+}
+
+func builtinContains(args []any) (any, error) {
+	if err := checkArgs("contains", args, 2, 2); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case *Array:
+		for _, e := range v.Elements {
+			if equalValues(e, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *Object:
+		key, ok := args[1].(string)
+		if !ok {
+			return false, nil
+		}
+		_, found := v.Get(key)
+		return found, nil
+	case string:
+		sub, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains: cannot search a string for %s", typeName(args[1]))
+		}
+		return strings.Contains(v, sub), nil
+	}
+	return nil, fmt.Errorf("contains: unsupported type %s", typeName(args[0]))
+}
+
+// builtinJoin concatenates the elements of an array with a separator.
+func builtinJoin(args []any) (any, error) {
+	if err := checkArgs("join", args, 1, 2); err != nil {
+		return nil, err
+	}
+	arr, err := arrayArg("join", args[0])
+	if err != nil {
+		return nil, err
+	}
+	sep := ","
+	if len(args) == 2 {
+		s, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("join separator must be a string, got %s", typeName(args[1]))
+		}
+		sep = s
+	}
+	parts := make([]string, len(arr.Elements))
+	for i, e := range arr.Elements {
+		parts[i] = formatValue(e)
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// -------------------- Conversion builtins ------------------------
+
+// builtinInt converts a number, numeric string or bool to an int. Floats
+// are truncated toward zero.
+func builtinInt(args []any) (any, error) {
+	if err := checkArgs("int", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return v, nil
+	case float64:
+		return floatToInt(v)
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		s := strings.TrimSpace(v)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return floatToInt(f)
+		}
+		return nil, fmt.Errorf("int: cannot parse %q", v)
+	}
+	return nil, fmt.Errorf("int: cannot convert %s", typeName(args[0]))
+}
+
+// builtinFloat converts a number, numeric string or bool to a float.
+func builtinFloat(args []any) (any, error) {
+	if err := checkArgs("float", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: cannot parse %q", v)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("float: cannot convert %s", typeName(args[0]))
+}
+
+// builtinStr formats any value the way print shows it.
+func builtinStr(args []any) (any, error) {
+	if err := checkArgs("str", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return formatValue(args[0]), nil
+}
+
+// -------------------- Error builtins ------------------------
+
+// builtinError creates an error object, error(message[, kind]), for use
+// with throw. Its location is filled in where it is thrown.
+func builtinError(args []any) (any, error) {
+	if err := checkArgs("error", args, 1, 2); err != nil {
+		return nil, err
+	}
+	e := &Error{Kind: KindError, Message: formatValue(args[0])}
+	if len(args) == 2 {
+		kind, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("error kind must be a string, got %s", typeName(args[1]))
+		}
+		e.Kind = kind
+	}
+	return e, nil
+}
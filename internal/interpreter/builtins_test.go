@@ -0,0 +1,129 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+)
+
+func TestListBuiltins(t *testing.T) {
+	list := func() *Array { return NewArray(int64(1), "two", int64(3)) }
+	tests := []struct {
+		name string
+		fn   func([]any) (any, error)
+		args []any
+		want string
+		err  string
+	}{
+		{name: "len array", fn: builtinLen, args: []any{list()}, want: "3"},
+		{name: "len string", fn: builtinLen, args: []any{"héllo"}, want: "5"},
+		{name: "len number", fn: builtinLen, args: []any{int64(1)}, err: "len: unsupported type int"},
+		{name: "push", fn: builtinPush, args: []any{list(), int64(4), int64(5)}, want: "5"},
+		{name: "push non-array", fn: builtinPush, args: []any{"s", int64(1)}, err: "push expects an array, got string"},
+		{name: "pop", fn: builtinPop, args: []any{list()}, want: "3"},
+		{name: "pop empty", fn: builtinPop, args: []any{NewArray()}, err: "pop from empty array"},
+		{name: "slice", fn: builtinSlice, args: []any{list(), int64(1)}, want: `["two", 3]`},
+		{name: "slice range", fn: builtinSlice, args: []any{list(), int64(0), int64(2)}, want: `[1, "two"]`},
+		{name: "slice string", fn: builtinSlice, args: []any{"héllo", int64(1), int64(3)}, want: "él"},
+		{name: "slice out of range", fn: builtinSlice, args: []any{list(), int64(1), int64(9)}, err: "slice end 9 out of range for length 3"},
+		{name: "slice reversed", fn: builtinSlice, args: []any{list(), int64(2), int64(1)}, err: "slice start 2 is after end 1"},
+		{name: "slice fraction", fn: builtinSlice, args: []any{list(), 1.5}, err: "slice start: expected a whole number, got 1.5"},
+		{name: "contains", fn: builtinContains, args: []any{list(), "two"}, want: "true"},
+		{name: "contains missing", fn: builtinContains, args: []any{list(), int64(2)}, want: "false"},
+		{name: "contains substring", fn: builtinContains, args: []any{"osun", "su"}, want: "true"},
+		{name: "join", fn: builtinJoin, args: []any{list()}, want: "1,two,3"},
+		{name: "join separator", fn: builtinJoin, args: []any{list(), " - "}, want: "1 - two - 3"},
+		{name: "too few arguments", fn: builtinJoin, args: nil, err: "join expects 1 to 2 arguments, got 0"},
+	}
+	for _, tt := range tests {
+		got, err := tt.fn(tt.args)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if s := formatValue(got); s != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, s, tt.want)
+		}
+	}
+}
+
+// push and pop change the array in place, so every reference sees it.
+func TestPushPopInPlace(t *testing.T) {
+	a := NewArray()
+	b := a
+	builtinPush([]any{a, "x", "y"})
+	builtinPop([]any{b})
+	if got := b.String(); got != `["x"]` {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestArrayJSON(t *testing.T) {
+	a := NewArray(int64(1), "two", true, nil, NewArray(float64(2.5), NewArray()))
+	data, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[1,"two",true,null,[2.5,[]]]`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestNestedJSONKeepsKeyOrder(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{`[{b: 1, a: 2}]`, `[{"b":1,"a":2}]`},
+		{`{z: [{y: 1, x: 2}], a: {d: [], c: [[{f: 1, e: 2}]]}}`,
+			`{"z":[{"y":1,"x":2}],"a":{"d":[],"c":[[{"f":1,"e":2}]]}}`},
+		{`[[], [null, {}], {k: [1.5, "s"]}]`, `[[],[null,{}],{"k":[1.5,"s"]}]`},
+	}
+	for _, tt := range tests {
+		in := New(Options{Output: io.Discard, Diagnostics: &diag.Printer{Out: io.Discard}})
+		if err := in.RunFile("test.os", "let v = "+tt.src); err != nil {
+			t.Fatalf("%s: %v", tt.src, err)
+		}
+		v, _ := in.GetVariable("v")
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.src, err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.src, data, tt.want)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	inner := NewArray(int64(1))
+	obj := NewObject()
+	obj.Set("b", inner)
+	obj.Set("a", inner)
+	loop := NewArray(obj)
+	loop.Elements = append(loop.Elements, loop)
+
+	c := snapshot(loop, nil).(*Array)
+	inner.Elements[0] = int64(2)
+	obj.Set("c", true)
+	if got, want := formatValue(c.Elements[0]), "{b: [1], a: [1]}"; got != want {
+		t.Errorf("copy changed with the original: %s, want %s", got, want)
+	}
+	if c.Elements[1] != c {
+		t.Error("cycle not preserved in the copy")
+	}
+	cobj := c.Elements[0].(*Object)
+	b, _ := cobj.Get("b")
+	a, _ := cobj.Get("a")
+	if a != b {
+		t.Error("shared array copied twice")
+	}
+	if snapshot("s", nil) != "s" || snapshot(nil, nil) != nil {
+		t.Error("snapshot changed a non-reference value")
+	}
+}
@@ -0,0 +1,50 @@
+package interpreter
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/parser"
+)
+
+// unit is a parsed file and the bytecode compiled from its functions. Units
+// are cached by path so that running a file again, or importing a module
+// again after a reload, only parses and compiles files whose content has
+// changed.
+type unit struct {
+	hash   [sha256.Size]byte
+	prog   *ast.Program
+	chunks map[*ast.FunctionLiteral]*chunk // nil for functions left to the tree-walker
+}
+
+// load parses a file's code, reusing the cached unit while the content
+// at path is unchanged. Code without a path is parsed but not cached.
+func (in *Interpreter) load(path, name, code string) (*unit, error) {
+	hash := sha256.Sum256([]byte(code))
+	if u, ok := in.units[path]; ok && u.hash == hash {
+		return u, nil
+	}
+	prog, err := parser.Parse(code)
+	if err != nil {
+		return nil, &syntaxError{file: name, list: err.(parser.ErrorList)}
+	}
+	u := &unit{hash: hash, prog: prog, chunks: map[*ast.FunctionLiteral]*chunk{}}
+	if path != "" {
+		in.units[path] = u
+	}
+	return u, nil
+}
+
+// Invalidate drops the cached parse and bytecode of the named files, for
+// example when a file watcher sees them change. Names are resolved against
+// the working directory.
+func (in *Interpreter) Invalidate(names ...string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for _, name := range names {
+		if abs, err := filepath.Abs(name); err == nil {
+			delete(in.units, abs)
+		}
+	}
+}
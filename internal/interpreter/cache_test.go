@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInvalidateReparsesChangedImport(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.os")
+	libPath := filepath.Join(dir, "lib.os")
+	mainSrc := "import { greet } from \"./lib\"\nprint(greet())"
+	write := func(path, src string) {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(mainPath, mainSrc)
+	write(libPath, `export fn greet() { return "hi" }`)
+
+	var out strings.Builder
+	in := New(Options{Output: &out})
+	runMain := func() {
+		t.Helper()
+		if err := in.RunFile(mainPath, mainSrc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runMain()
+	mainUnit, libUnit := in.units[mainPath], in.units[libPath]
+	if mainUnit == nil || libUnit == nil {
+		t.Fatalf("units not cached: %v", in.units)
+	}
+
+	runMain()
+	if in.units[mainPath] != mainUnit || in.units[libPath] != libUnit {
+		t.Error("rerunning unchanged files parsed them again")
+	}
+
+	write(libPath, `export fn greet() { return "hello" }`)
+	in.Invalidate(libPath)
+	runMain()
+	if in.units[mainPath] != mainUnit {
+		t.Error("main.os was parsed again although only lib.os changed")
+	}
+	if in.units[libPath] == libUnit {
+		t.Error("lib.os was not parsed again after Invalidate")
+	}
+	if want := "hi\nhi\nhello\n"; out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
+
+func TestInvalidateKeepsUnchangedModules(t *testing.T) {
+	const (
+		lib     = "testdata/modules/lib.os"
+		counter = "testdata/modules/counter.os"
+	)
+	src := `import { greet } from "./testdata/modules/lib"
+print(greet("x"))`
+	var out strings.Builder
+	in := New(Options{Output: &out})
+	units := func() (*unit, *unit) {
+		t.Helper()
+		if err := in.RunFile("test.os", src); err != nil {
+			t.Fatal(err)
+		}
+		libPath, _ := filepath.Abs(lib)
+		counterPath, _ := filepath.Abs(counter)
+		return in.units[libPath], in.units[counterPath]
+	}
+
+	libUnit, counterUnit := units()
+	if libUnit == nil || counterUnit == nil {
+		t.Fatalf("modules not cached: %v", in.units)
+	}
+	if l, c := units(); l != libUnit || c != counterUnit {
+		t.Error("rerunning parsed unchanged modules again")
+	}
+
+	in.Invalidate(lib)
+	l, c := units()
+	if l == libUnit {
+		t.Error("lib.os was not parsed again after Invalidate")
+	}
+	if c != counterUnit {
+		t.Error("counter.os was parsed again although only lib.os was invalidated")
+	}
+	if again, _ := units(); again != l {
+		t.Error("lib.os was not cached again after being reparsed")
+	}
+	if want := strings.Repeat("counter loaded\nhi x\n", 4); out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,346 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/intellidevelopers/osun-lang/internal/runtime"
+)
+
+// coerceArgs converts Osun values into the parameter types of the Go
+// function type ft, expanding variadic parameters. The values in lead are
+// passed unchanged as the first parameters, ahead of args.
+func (th *thread) coerceArgs(ft reflect.Type, lead []reflect.Value, args []any) ([]reflect.Value, error) {
+	fixed := ft.NumIn() - len(lead)
+	if ft.IsVariadic() {
+		fixed--
+		if len(args) < fixed {
+			return nil, fmt.Errorf("expects at least %d arguments, got %d", fixed, len(args))
+		}
+	} else if len(args) != fixed {
+		return nil, fmt.Errorf("expects %d arguments, got %d", fixed, len(args))
+	}
+
+	vals := append(make([]reflect.Value, 0, len(lead)+len(args)), lead...)
+	for i, arg := range args {
+		var t reflect.Type
+		if i < fixed {
+			t = ft.In(len(lead) + i)
+		} else {
+			t = ft.In(ft.NumIn() - 1).Elem()
+		}
+		v, err := th.coerce(arg, t)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i+1, err)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// coerce converts an Osun value to a reflect.Value of type t.
+func (th *thread) coerce(v any, t reflect.Type) (reflect.Value, error) {
+	if v == nil {
+		switch t.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use null as %s", t)
+	}
+
+	// Untyped parameters receive arrays and objects as they are, so that
+	// JSON encoding keeps object keys in order, but as snapshots, since
+	// the Go code reads them with the interpreter unlocked.
+	if rv := reflect.ValueOf(v); rv.Type().AssignableTo(t) {
+		return reflect.ValueOf(snapshot(v, th)), nil
+	}
+
+	switch val := v.(type) {
+	case int64:
+		return coerceInt(val, t)
+	case float64:
+		return coerceNumber(val, t)
+	case bool:
+		if t.Kind() == reflect.Bool {
+			return reflect.ValueOf(val).Convert(t), nil
+		}
+	case string:
+		if t.Kind() == reflect.String {
+			return reflect.ValueOf(val).Convert(t), nil
+		}
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return reflect.ValueOf([]byte(val)).Convert(t), nil
+		}
+	case *Array:
+		return th.coerceArray(val, t)
+	case *Object:
+		return th.coerceObject(val, t)
+	case runtime.Callable:
+		if t.Kind() == reflect.Func {
+			return th.makeGoFunc(val, t), nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use %s as %s", typeName(v), t)
+}
+
+func coerceNumber(n float64, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n != math.Trunc(n) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s: not a whole number", n, t)
+		}
+		if n < math.MinInt64 || n >= math.MaxInt64 || out.OverflowInt(int64(n)) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s: out of range", n, t)
+		}
+		out.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n != math.Trunc(n) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s: not a whole number", n, t)
+		}
+		if n < 0 || n >= math.MaxUint64 || out.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s: out of range", n, t)
+		}
+		out.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		if out.OverflowFloat(n) {
+			return reflect.Value{}, fmt.Errorf("cannot use %v as %s: out of range", n, t)
+		}
+		out.SetFloat(n)
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot use float as %s", t)
+	}
+	return out, nil
+}
+
+func coerceInt(n int64, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if out.OverflowInt(n) {
+			return reflect.Value{}, fmt.Errorf("cannot use %d as %s: out of range", n, t)
+		}
+		out.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n < 0 || out.OverflowUint(uint64(n)) {
+			return reflect.Value{}, fmt.Errorf("cannot use %d as %s: out of range", n, t)
+		}
+		out.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		out.SetFloat(float64(n))
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot use int as %s", t)
+	}
+	return out, nil
+}
+
+func (th *thread) coerceArray(arr *Array, t reflect.Type) (reflect.Value, error) {
+	switch t.Kind() {
+	case reflect.Slice:
+		out := reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements))
+		for i, e := range arr.Elements {
+			ev, err := th.coerce(e, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			out.Index(i).Set(ev)
+		}
+		return out, nil
+	case reflect.Array:
+		if len(arr.Elements) != t.Len() {
+			return reflect.Value{}, fmt.Errorf("cannot use array of length %d as %s", len(arr.Elements), t)
+		}
+		out := reflect.New(t).Elem()
+		for i, e := range arr.Elements {
+			ev, err := th.coerce(e, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			out.Index(i).Set(ev)
+		}
+		return out, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use array as %s", t)
+}
+
+func (th *thread) coerceObject(obj *Object, t reflect.Type) (reflect.Value, error) {
+	switch {
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+		out := reflect.MakeMapWithSize(t, obj.Len())
+		for _, k := range obj.Keys() {
+			val, _ := obj.Get(k)
+			ev, err := th.coerce(val, t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("field %s: %v", k, err)
+			}
+			out.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), ev)
+		}
+		return out, nil
+	case t.Kind() == reflect.Struct:
+		return th.coerceStruct(obj, t)
+	case t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct:
+		sv, err := th.coerceStruct(obj, t.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(t.Elem())
+		ptr.Elem().Set(sv)
+		return ptr, nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot use object as %s", t)
+}
+
+// coerceStruct fills the exported fields of a struct from an object. A field
+// is matched by its json tag name, its Go name, or its Go name with a
+// lower-case first letter; keys with no matching field are ignored.
+func (th *thread) coerceStruct(obj *Object, t reflect.Type) (reflect.Value, error) {
+	out := reflect.New(t).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		val, ok := lookupField(obj, f)
+		if !ok {
+			continue
+		}
+		fv, err := th.coerce(val, f.Type)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		out.Field(i).Set(fv)
+	}
+	return out, nil
+}
+
+func lookupField(obj *Object, f reflect.StructField) (any, bool) {
+	if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+		if v, ok := obj.Get(tag); ok {
+			return v, true
+		}
+	}
+	if v, ok := obj.Get(f.Name); ok {
+		return v, true
+	}
+	return obj.Get(strings.ToLower(f.Name[:1]) + f.Name[1:])
+}
+
+// makeGoFunc wraps a script function or builtin that th passes to Go in a
+// Go function of type t, so it can be given to builtins expecting
+// callbacks, such as http.HandlerFunc. Go arguments are converted to Osun
+// values and the result back to t's first return type. A script function
+// may declare fewer parameters than t passes; the extra arguments are
+// dropped. If t has a trailing error result, script errors are returned
+// through it; otherwise they are reported and zero values are returned, and
+// an HTTP handler that has not started its response answers 500 Internal
+// Server Error.
+func (th *thread) makeGoFunc(fn runtime.Callable, t reflect.Type) reflect.Value {
+	return reflect.MakeFunc(t, func(goArgs []reflect.Value) []reflect.Value {
+		w := wrapResponseWriter(goArgs)
+
+		// Each call, such as one HTTP request, runs on a new thread with
+		// the interpreter locked: the one fn belongs to, or for a builtin
+		// the one that passed it to Go. A call made while th waits for the
+		// Go function it passed fn to continues th.
+		interp := th.in
+		if f, ok := fn.(*Function); ok {
+			interp = f.module.in
+		}
+		interp.mu.Lock()
+		defer interp.mu.Unlock()
+
+		var cb *thread
+		var cancel context.CancelFunc
+		if r := requestOf(goArgs); r != nil {
+			cb, cancel = interp.newThread(r.Context(), interp.opts.RequestTimeout)
+			cb.pushOrigin(r)
+		} else {
+			cb, cancel = interp.callbackThread(th, context.Background(), interp.opts.Timeout)
+		}
+		defer cancel()
+
+		call := fn.Call
+		switch f := fn.(type) {
+		case *Function:
+			call = func(args ...any) (any, error) { return cb.callUserFunction(f, args) }
+			if len(goArgs) > f.Arity() {
+				goArgs = goArgs[:f.Arity()]
+			}
+		case *Builtin:
+			call = func(args ...any) (any, error) { return cb.callValue(f, args) }
+		}
+		args := make([]any, len(goArgs))
+		for i, a := range goArgs {
+			args[i] = fromGoValue(a.Interface())
+		}
+
+		out := make([]reflect.Value, t.NumOut())
+		for i := range out {
+			out[i] = reflect.Zero(t.Out(i))
+		}
+		hasErr := t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType
+		fail := func(err error) []reflect.Value {
+			if hasErr {
+				out[len(out)-1] = reflect.ValueOf(&err).Elem()
+				return out
+			}
+			interp.report(err)
+			if w != nil && !w.started {
+				code := http.StatusInternalServerError
+				http.Error(w.ResponseWriter, http.StatusText(code), code)
+			}
+			return out
+		}
+
+		result, err := call(args...)
+		if err != nil {
+			return fail(err)
+		}
+		if t.NumOut() > 0 && !(hasErr && t.NumOut() == 1) {
+			rv, err := cb.coerce(result, t.Out(0))
+			if err != nil {
+				return fail(fmt.Errorf("return value: %v", err))
+			}
+			out[0] = rv
+		}
+		return out
+	})
+}
+
+// responseWriter records whether a handler has started its response, so a
+// failed handler can still send an error status.
+type responseWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.started = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// wrapResponseWriter replaces the http.ResponseWriter among the arguments
+// of a call from Go with a responseWriter and returns it, or returns nil
+// when the call is not serving a request.
+func wrapResponseWriter(args []reflect.Value) *responseWriter {
+	for i, a := range args {
+		if rw, ok := a.Interface().(http.ResponseWriter); ok {
+			w := &responseWriter{ResponseWriter: rw}
+			args[i] = reflect.ValueOf(w)
+			return w
+		}
+	}
+	return nil
+}
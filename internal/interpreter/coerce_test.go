@@ -0,0 +1,156 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+)
+
+// serve runs src, which mounts script handlers with route(path, handler),
+// and returns the mux serving them.
+func serve(t *testing.T, in *Interpreter, src string) *http.ServeMux {
+	t.Helper()
+	mux := http.NewServeMux()
+	in.SetVariable("route", func(path string, h http.HandlerFunc) { mux.HandleFunc(path, h) })
+	if err := in.RunFile("test.os", src); err != nil {
+		t.Fatal(err)
+	}
+	return mux
+}
+
+func TestFailedHandlerResponds500(t *testing.T) {
+	var diags strings.Builder
+	in := New(Options{
+		Output:         io.Discard,
+		Diagnostics:    &diag.Printer{Out: &diags},
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	mux := serve(t, in, `route("/throw", fn(w, r) { throw "boom" })
+route("/undefined", fn() { missing() })
+route("/slow", fn(w, r) { while true {} })
+route("/written", fn(w, r) {
+  w.WriteHeader(201)
+  throw "after writing"
+})
+route("/ok", fn(w, r) { w.WriteHeader(204) })`)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/throw", 500},
+		{"/undefined", 500},
+		{"/slow", 500},
+		{"/written", 201},
+		{"/ok", 204},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.path, rec.Code, tt.status)
+		}
+		if tt.status == 500 && strings.TrimSpace(rec.Body.String()) != "Internal Server Error" {
+			t.Errorf("%s: body %q", tt.path, rec.Body.String())
+		}
+	}
+	for _, want := range []string{"uncaught exception: boom", "undefined variable missing", "TimeoutError", "after writing"} {
+		if !strings.Contains(diags.String(), want) {
+			t.Errorf("diagnostics missing %q:\n%s", want, diags.String())
+		}
+	}
+}
+
+// A builtin passed to Go as a callback runs under the lock of the
+// interpreter that passed it, not the package's Default one.
+func TestBuiltinCallbackUsesOwnInterpreter(t *testing.T) {
+	Default.mu.Lock()
+	defer Default.mu.Unlock()
+
+	var out strings.Builder
+	in := New(Options{Output: &out})
+	in.SetVariable("apply", func(f func(any) (any, error), v any) (any, error) { return f(v) })
+	done := make(chan error, 1)
+	go func() { done <- in.RunFile("test.os", `print(apply(str, 5) + "!")`) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback is waiting for the Default interpreter")
+	}
+	if out.String() != "5!\n" {
+		t.Errorf("output %q", out.String())
+	}
+}
+
+type point struct {
+	X     int
+	Y     int `json:"yy"`
+	Label string
+	Tags  []string
+}
+
+func TestCoerceArgs(t *testing.T) {
+	funcs := map[string]any{
+		"asInt":     func(n int) int { return n },
+		"asInt8":    func(n int8) int8 { return n },
+		"asInt16":   func(n int16) int16 { return n },
+		"asInt32":   func(n int32) int32 { return n },
+		"asUint":    func(n uint) uint { return n },
+		"asUint8":   func(n uint8) uint8 { return n },
+		"asUint16":  func(n uint16) uint16 { return n },
+		"asUint64":  func(n uint64) uint64 { return n },
+		"asFloat32": func(f float32) float32 { return f },
+		"point":     func(p point) string { return fmt.Sprintf("%+v", p) },
+		"ptr":       func(p *point) string { return fmt.Sprintf("%+v", *p) },
+		"sum": func(base int, ns ...int) int {
+			for _, n := range ns {
+				base += n
+			}
+			return base
+		},
+		"bytes": func(b []byte) int { return len(b) },
+		"pair":  func(a [2]string) string { return a[0] + a[1] },
+	}
+	runScriptTests(t, []scriptTest{
+		{name: "int", vars: funcs, src: `print(asInt(42), asInt(-7), asInt(3.0))`, out: "42\n-7\n3\n"},
+		{name: "sized ints", vars: funcs, src: `print(asInt8(-128), asInt16(32767), asInt32(-5))`, out: "-128\n32767\n-5\n"},
+		{name: "uints", vars: funcs, src: `print(asUint(7), asUint8(255), asUint16(2.0), asUint64(9223372036854775807))`,
+			out: "7\n255\n2\n9223372036854775807\n"},
+		{name: "int to float", vars: funcs, src: `print(asFloat32(2), asFloat32(0.5))`, out: "2.0\n0.5\n"},
+		{name: "int8 out of range", vars: funcs, src: `asInt8(128)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 128 as int8: out of range"},
+		{name: "int16 out of range", vars: funcs, src: `asInt16(-40000)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use -40000 as int16: out of range"},
+		{name: "negative uint", vars: funcs, src: `asUint(-1)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use -1 as uint: out of range"},
+		{name: "uint8 out of range", vars: funcs, src: `asUint8(256.0)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 256 as uint8: out of range"},
+		{name: "float out of int range", vars: funcs, src: `asInt(1e19)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 1e+19 as int: out of range"},
+		{name: "float32 out of range", vars: funcs, src: `asFloat32(1e39)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 1e+39 as float32: out of range"},
+		{name: "fractional float to int", vars: funcs, src: `asInt(2.5)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 2.5 as int: not a whole number"},
+		{name: "fractional float to uint", vars: funcs, src: `asUint(0.5)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use 0.5 as uint: not a whole number"},
+		{name: "string to int", vars: funcs, src: `asInt("1")`, errKind: KindBuiltin, errMsg: "argument 1: cannot use string as int"},
+		{name: "null to int", vars: funcs, src: `asInt(null)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use null as int"},
+		{name: "bool to uint", vars: funcs, src: `asUint(true)`, errKind: KindBuiltin, errMsg: "argument 1: cannot use bool as uint"},
+		{name: "too few arguments", vars: funcs, src: `asInt()`, errKind: KindBuiltin, errMsg: "expects 1 arguments, got 0"},
+		{name: "too many arguments", vars: funcs, src: `asInt(1, 2)`, errKind: KindBuiltin, errMsg: "expects 1 arguments, got 2"},
+		{name: "struct", vars: funcs, src: `print(point({X: 1, yy: 2, label: "a", tags: ["t"], extra: true}))`,
+			out: "{X:1 Y:2 Label:a Tags:[t]}\n"},
+		{name: "struct pointer", vars: funcs, src: `print(ptr({x: 3}))`, out: "{X:3 Y:0 Label: Tags:[]}\n"},
+		{name: "struct field error", vars: funcs, src: `point({X: "one"})`, errKind: KindBuiltin, errMsg: "argument 1: field X: cannot use string as int"},
+		{name: "struct element error", vars: funcs, src: `point({tags: ["a", 2]})`,
+			errKind: KindBuiltin, errMsg: "argument 1: field Tags: element 1: cannot use int as string"},
+		{name: "array to struct", vars: funcs, src: `point([1])`, errKind: KindBuiltin, errMsg: "argument 1: cannot use array as interpreter.point"},
+		{name: "variadic", vars: funcs, src: `print(sum(1), sum(1, 2, 3))`, out: "1\n6\n"},
+		{name: "variadic too few", vars: funcs, src: `sum()`, errKind: KindBuiltin, errMsg: "expects at least 1 arguments, got 0"},
+		{name: "variadic element error", vars: funcs, src: `sum(1, 2, 3.5)`, errKind: KindBuiltin, errMsg: "argument 3: cannot use 3.5 as int: not a whole number"},
+		{name: "string to bytes", vars: funcs, src: `print(bytes("héllo"))`, out: "6\n"},
+		{name: "fixed-size array", vars: funcs, src: `print(pair(["a", "b"]))`, out: "ab\n"},
+		{name: "fixed-size array length", vars: funcs, src: `pair(["a"])`, errKind: KindBuiltin, errMsg: "argument 1: cannot use array of length 1 as [2]string"},
+	})
+}
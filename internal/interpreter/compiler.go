@@ -0,0 +1,584 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// -------------------- Bytecode ------------------------
+
+// opcode is one VM instruction. Operands a and b are described next to
+// each opcode; "node" operands index chunk.nodes, which supplies error
+// positions and the AST detail an instruction needs.
+type opcode uint8
+
+const (
+	opConst    opcode = iota // push consts[a]
+	opPop                    // discard the top of the stack
+	opGetLocal               // push locals[a]
+	opSetLocal               // pop into locals[a]
+	opGetName                // push the free variable node a from the closure
+
+	opUpdateLocal // pop a value and store node b's update of locals[a] by it
+	opAssignName  // pop into the free variable node a; node b, if any, is an update
+	opCheckField  // fail unless the top of the stack is an object; node a is the target
+	opSetField    // pop value, object; set field node a; node b, if any, is an update
+	opSetIndex    // pop value, index, object; set element node a; node b, if any, is an update
+
+	opJump            // jump to a
+	opJumpIfFalse     // pop; jump to a if falsy
+	opJumpIfFalseKeep // jump to a if the top is false, otherwise pop it
+	opJumpIfTrueKeep  // jump to a if the top is true, otherwise pop it
+	opTruthy          // replace the top with its truthiness
+
+	opUnary  // apply unary node a to the top
+	opBinary // pop right, left; push binary node a applied to them
+	opIndex  // pop index, object; push element for node a
+	opMember // pop object; push member node a
+	opArray  // pop a values; push them as an array
+	opObject // pop one value per key of object literal node a
+	opConcat // pop a values; push their formatted concatenation
+
+	opCall     // pop callee and b arguments; call for node a
+	opCallPath // pop b arguments; call the callSite consts[a], resolving its callee in the closure
+	opReturn   // pop the function's result and return it
+	opThrow    // pop and throw for node a
+
+	opStep      // count statement node a against the thread's limits
+	opResetLoop // zero loop counter a
+	opLoopGuard // count an iteration of loop node b on counter a
+	opForIn     // pop the iterable (or range bounds) and run loop a of chunk.loops
+	opTry       // run try statement a of chunk.tries
+	opNop       // do nothing; marks the end of a for-in body
+)
+
+// callSite is a call whose dotted callee is resolved when it runs.
+type callSite struct {
+	call *ast.CallExpression
+	path []string
+}
+
+type instr struct {
+	op   opcode
+	a, b int
+}
+
+// chunk is a function body compiled to bytecode.
+type chunk struct {
+	code     []instr
+	consts   []any
+	nodes    []ast.Node
+	loops    []forInfo
+	tries    []tryInfo
+	locals   int // number of local slots, parameters first
+	counters int // number of while-loop iteration counters
+}
+
+// forInfo lays out a for-in loop: the body occupies [body, end) and is run
+// once per element, and break jumps to end+1.
+type forInfo struct {
+	stmt       *ast.ForInStatement
+	key, value int // local slots; key is -1 when the loop has none
+	body, end  int
+}
+
+// tryInfo lays out a try statement. Each block occupies [start, end) of
+// the code; after is where execution continues.
+type tryInfo struct {
+	stmt            *ast.TryStatement
+	body, bodyEnd   int
+	catch, catchEnd int
+	param           int // local slot of the catch variable, or -1
+	final, finalEnd int
+	after           int
+}
+
+// -------------------- Compiler ------------------------
+
+// errUnsupported reports a function the compiler leaves to the tree-walking
+// evaluator: one that creates closures, which capture scopes as
+// environments, or whose code relies on a check the evaluator makes when
+// it runs, such as assigning to a constant.
+var errUnsupported = errors.New("not compilable to bytecode")
+
+// compiler turns a function body into a chunk. Block scopes map names to
+// local slots at compile time; names not bound in the function are looked
+// up in its closure when the code runs.
+type compiler struct {
+	c      *chunk
+	scopes []map[string]binding
+	loops  []*loopLabels
+}
+
+type binding struct {
+	slot    int
+	isConst bool
+}
+
+// loopLabels collects the jumps of break and continue statements inside a
+// loop until the loop's layout is known.
+type loopLabels struct {
+	breaks, continues []int
+}
+
+// compileFunction compiles fn's body, or returns errUnsupported.
+func compileFunction(fn *ast.FunctionLiteral) (*chunk, error) {
+	cp := &compiler{c: &chunk{}}
+	cp.openScope()
+	for _, p := range fn.Params {
+		cp.declare(p.Name, false)
+	}
+	if err := cp.block(fn.Body.Statements); err != nil {
+		return nil, err
+	}
+	cp.emit(opConst, cp.constant(nil), 0)
+	cp.emit(opReturn, 0, 0)
+	return cp.c, nil
+}
+
+func (cp *compiler) emit(op opcode, a, b int) int {
+	cp.c.code = append(cp.c.code, instr{op: op, a: a, b: b})
+	return len(cp.c.code) - 1
+}
+
+func (cp *compiler) here() int { return len(cp.c.code) }
+
+func (cp *compiler) patch(at int) { cp.c.code[at].a = cp.here() }
+
+func (cp *compiler) constant(v any) int {
+	cp.c.consts = append(cp.c.consts, v)
+	return len(cp.c.consts) - 1
+}
+
+func (cp *compiler) node(n ast.Node) int {
+	cp.c.nodes = append(cp.c.nodes, n)
+	return len(cp.c.nodes) - 1
+}
+
+func (cp *compiler) openScope()  { cp.scopes = append(cp.scopes, map[string]binding{}) }
+func (cp *compiler) closeScope() { cp.scopes = cp.scopes[:len(cp.scopes)-1] }
+
+// declare binds name in the innermost scope. As in Environment.Declare, a
+// second let in the same scope reuses the binding.
+func (cp *compiler) declare(name string, isConst bool) (int, error) {
+	scope := cp.scopes[len(cp.scopes)-1]
+	if b, ok := scope[name]; ok {
+		if b.isConst {
+			return 0, errUnsupported
+		}
+		scope[name] = binding{slot: b.slot, isConst: isConst}
+		return b.slot, nil
+	}
+	slot := cp.c.locals
+	cp.c.locals++
+	scope[name] = binding{slot: slot, isConst: isConst}
+	return slot, nil
+}
+
+func (cp *compiler) resolve(name string) (binding, bool) {
+	for i := len(cp.scopes) - 1; i >= 0; i-- {
+		if b, ok := cp.scopes[i][name]; ok {
+			return b, true
+		}
+	}
+	return binding{}, false
+}
+
+// -------------------- Statements ------------------------
+
+func (cp *compiler) block(stmts []ast.Statement) error {
+	for _, s := range stmts {
+		if err := cp.statement(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scopedBlock compiles stmts in a new block scope.
+func (cp *compiler) scopedBlock(stmts []ast.Statement) error {
+	cp.openScope()
+	defer cp.closeScope()
+	return cp.block(stmts)
+}
+
+func (cp *compiler) statement(stmt ast.Statement) error {
+	cp.emit(opStep, cp.node(stmt), 0)
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		if err := cp.expr(s.Value); err != nil {
+			return err
+		}
+		slot, err := cp.declare(s.Name.Name, s.Const)
+		if err != nil {
+			return err
+		}
+		cp.emit(opSetLocal, slot, 0)
+	case *ast.IfStatement:
+		return cp.ifStatement(s)
+	case *ast.WhileStatement:
+		return cp.whileStatement(s)
+	case *ast.ForInStatement:
+		return cp.forIn(s)
+	case *ast.BreakStatement, *ast.ContinueStatement:
+		if len(cp.loops) == 0 {
+			return errUnsupported
+		}
+		loop := cp.loops[len(cp.loops)-1]
+		at := cp.emit(opJump, 0, 0)
+		if _, ok := s.(*ast.BreakStatement); ok {
+			loop.breaks = append(loop.breaks, at)
+		} else {
+			loop.continues = append(loop.continues, at)
+		}
+	case *ast.ReturnStatement:
+		if s.Value == nil {
+			cp.emit(opConst, cp.constant(nil), 0)
+		} else if err := cp.expr(s.Value); err != nil {
+			return err
+		}
+		cp.emit(opReturn, 0, 0)
+	case *ast.TryStatement:
+		return cp.try(s)
+	case *ast.ThrowStatement:
+		if err := cp.expr(s.Value); err != nil {
+			return err
+		}
+		cp.emit(opThrow, cp.node(s), 0)
+	case *ast.AssignStatement:
+		return cp.assign(s, s.Target, s.Value)
+	case *ast.IncDecStatement:
+		return cp.assign(s, s.Target, nil)
+	case *ast.BlockStatement:
+		return cp.scopedBlock(s.Statements)
+	case *ast.ExpressionStatement:
+		if err := cp.expr(s.Expr); err != nil {
+			return err
+		}
+		cp.emit(opPop, 0, 0)
+	default:
+		// Nested functions, imports and exports.
+		return errUnsupported
+	}
+	return nil
+}
+
+func (cp *compiler) ifStatement(s *ast.IfStatement) error {
+	if err := cp.expr(s.Condition); err != nil {
+		return err
+	}
+	skip := cp.emit(opJumpIfFalse, 0, 0)
+	if err := cp.scopedBlock(s.Consequence.Statements); err != nil {
+		return err
+	}
+	if s.Alternative == nil {
+		cp.patch(skip)
+		return nil
+	}
+	done := cp.emit(opJump, 0, 0)
+	cp.patch(skip)
+	if err := cp.statement(s.Alternative); err != nil {
+		return err
+	}
+	cp.patch(done)
+	return nil
+}
+
+func (cp *compiler) whileStatement(s *ast.WhileStatement) error {
+	counter := cp.c.counters
+	cp.c.counters++
+	cp.emit(opResetLoop, counter, 0)
+	top := cp.here()
+	if err := cp.expr(s.Condition); err != nil {
+		return err
+	}
+	exit := cp.emit(opJumpIfFalse, 0, 0)
+	cp.emit(opLoopGuard, counter, cp.node(s))
+
+	labels := &loopLabels{}
+	cp.loops = append(cp.loops, labels)
+	err := cp.scopedBlock(s.Body.Statements)
+	cp.loops = cp.loops[:len(cp.loops)-1]
+	if err != nil {
+		return err
+	}
+	cp.emit(opJump, top, 0)
+	cp.patch(exit)
+	for _, at := range labels.continues {
+		cp.c.code[at].a = top
+	}
+	for _, at := range labels.breaks {
+		cp.patch(at)
+	}
+	return nil
+}
+
+// forIn compiles a for-in loop. The iterable is evaluated onto the stack
+// (both bounds for a range) and opForIn runs the body once per element.
+func (cp *compiler) forIn(s *ast.ForInStatement) error {
+	if r, ok := s.Iterable.(*ast.RangeExpression); ok {
+		if err := cp.expr(r.Start); err != nil {
+			return err
+		}
+		if err := cp.expr(r.End); err != nil {
+			return err
+		}
+	} else if err := cp.expr(s.Iterable); err != nil {
+		return err
+	}
+
+	info := forInfo{stmt: s, key: -1}
+	index := len(cp.c.loops)
+	cp.c.loops = append(cp.c.loops, info)
+	cp.emit(opForIn, index, 0)
+
+	cp.openScope()
+	if s.Key != nil {
+		info.key, _ = cp.declare(s.Key.Name, false)
+	}
+	info.value, _ = cp.declare(s.Value.Name, false)
+	info.body = cp.here()
+	labels := &loopLabels{}
+	cp.loops = append(cp.loops, labels)
+	err := cp.block(s.Body.Statements)
+	cp.loops = cp.loops[:len(cp.loops)-1]
+	cp.closeScope()
+	if err != nil {
+		return err
+	}
+
+	info.end = cp.here()
+	cp.emit(opNop, 0, 0)
+	for _, at := range labels.continues {
+		cp.c.code[at].a = info.end
+	}
+	for _, at := range labels.breaks {
+		cp.c.code[at].a = info.end + 1
+	}
+	cp.c.loops[index] = info
+	return nil
+}
+
+func (cp *compiler) try(s *ast.TryStatement) error {
+	info := tryInfo{stmt: s, param: -1}
+	index := len(cp.c.tries)
+	cp.c.tries = append(cp.c.tries, info)
+	cp.emit(opTry, index, 0)
+
+	info.body = cp.here()
+	if err := cp.scopedBlock(s.Body.Statements); err != nil {
+		return err
+	}
+	info.bodyEnd = cp.here()
+
+	info.catch = cp.here()
+	if s.Catch != nil {
+		cp.openScope()
+		if s.CatchParam != nil {
+			info.param, _ = cp.declare(s.CatchParam.Name, false)
+		}
+		err := cp.block(s.Catch.Statements)
+		cp.closeScope()
+		if err != nil {
+			return err
+		}
+	}
+	info.catchEnd = cp.here()
+
+	info.final = cp.here()
+	if s.Finally != nil {
+		if err := cp.scopedBlock(s.Finally.Statements); err != nil {
+			return err
+		}
+	}
+	info.finalEnd = cp.here()
+	info.after = cp.here()
+	cp.c.tries[index] = info
+	return nil
+}
+
+// assign compiles an assignment or, when value is nil, an x++ or x--
+// statement. The target's object and index are evaluated before the value.
+func (cp *compiler) assign(stmt ast.Statement, target, value ast.Expression) error {
+	update := -1
+	if a, ok := stmt.(*ast.AssignStatement); !ok || isCompound(a) {
+		update = cp.node(stmt)
+	}
+	rhs := func() error {
+		if value == nil {
+			cp.emit(opConst, cp.constant(nil), 0)
+			return nil
+		}
+		return cp.expr(value)
+	}
+
+	switch t := target.(type) {
+	case *ast.Identifier:
+		if err := rhs(); err != nil {
+			return err
+		}
+		b, local := cp.resolve(t.Name)
+		if !local {
+			cp.emit(opAssignName, cp.node(t), update)
+			return nil
+		}
+		if b.isConst {
+			return errUnsupported
+		}
+		if update < 0 {
+			cp.emit(opSetLocal, b.slot, 0)
+		} else {
+			cp.emit(opUpdateLocal, b.slot, update)
+		}
+	case *ast.IndexExpression:
+		if err := cp.expr(t.Object); err != nil {
+			return err
+		}
+		if err := cp.expr(t.Index); err != nil {
+			return err
+		}
+		if err := rhs(); err != nil {
+			return err
+		}
+		cp.emit(opSetIndex, cp.node(t), update)
+	case *ast.MemberExpression:
+		if err := cp.expr(t.Object); err != nil {
+			return err
+		}
+		n := cp.node(t)
+		cp.emit(opCheckField, n, 0)
+		if err := rhs(); err != nil {
+			return err
+		}
+		cp.emit(opSetField, n, update)
+	default:
+		return errUnsupported
+	}
+	return nil
+}
+
+// -------------------- Expressions ------------------------
+
+func (cp *compiler) expr(expr ast.Expression) error {
+	switch e := expr.(type) {
+	case *ast.StringLiteral:
+		cp.emit(opConst, cp.constant(e.Value), 0)
+	case *ast.NumberLiteral:
+		cp.emit(opConst, cp.constant(e.Value), 0)
+	case *ast.BooleanLiteral:
+		cp.emit(opConst, cp.constant(e.Value), 0)
+	case *ast.NullLiteral:
+		cp.emit(opConst, cp.constant(nil), 0)
+	case *ast.TemplateLiteral:
+		if err := cp.exprs(e.Parts); err != nil {
+			return err
+		}
+		cp.emit(opConcat, len(e.Parts), 0)
+	case *ast.Identifier:
+		if b, ok := cp.resolve(e.Name); ok {
+			cp.emit(opGetLocal, b.slot, 0)
+		} else {
+			cp.emit(opGetName, cp.node(e), 0)
+		}
+	case *ast.UnaryExpression:
+		if err := cp.expr(e.Operand); err != nil {
+			return err
+		}
+		cp.emit(opUnary, cp.node(e), 0)
+	case *ast.BinaryExpression:
+		return cp.binary(e)
+	case *ast.CallExpression:
+		return cp.call(e)
+	case *ast.MemberExpression:
+		if err := cp.expr(e.Object); err != nil {
+			return err
+		}
+		cp.emit(opMember, cp.node(e), 0)
+	case *ast.IndexExpression:
+		if err := cp.expr(e.Object); err != nil {
+			return err
+		}
+		if err := cp.expr(e.Index); err != nil {
+			return err
+		}
+		cp.emit(opIndex, cp.node(e), 0)
+	case *ast.ArrayLiteral:
+		if err := cp.exprs(e.Elements); err != nil {
+			return err
+		}
+		cp.emit(opArray, len(e.Elements), 0)
+	case *ast.ObjectLiteral:
+		if err := cp.exprs(e.Values); err != nil {
+			return err
+		}
+		cp.emit(opObject, cp.node(e), 0)
+	default:
+		// Function literals, and ranges outside a for-in loop.
+		return errUnsupported
+	}
+	return nil
+}
+
+func (cp *compiler) exprs(list []ast.Expression) error {
+	for _, e := range list {
+		if err := cp.expr(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binary compiles a binary expression; && and || skip the right operand
+// when the left one decides the result.
+func (cp *compiler) binary(e *ast.BinaryExpression) error {
+	if err := cp.expr(e.Left); err != nil {
+		return err
+	}
+	if e.Op != token.AND && e.Op != token.OR {
+		if err := cp.expr(e.Right); err != nil {
+			return err
+		}
+		cp.emit(opBinary, cp.node(e), 0)
+		return nil
+	}
+	cp.emit(opTruthy, 0, 0)
+	jump := opJumpIfFalseKeep
+	if e.Op == token.OR {
+		jump = opJumpIfTrueKeep
+	}
+	skip := cp.emit(jump, 0, 0)
+	if err := cp.expr(e.Right); err != nil {
+		return err
+	}
+	cp.emit(opTruthy, 0, 0)
+	cp.patch(skip)
+	return nil
+}
+
+// call compiles a call. Arguments are evaluated before the callee. A
+// dotted callee rooted outside the function's locals may name print or a
+// runtime symbol, so it is resolved when the call runs, as evalCall does.
+func (cp *compiler) call(e *ast.CallExpression) error {
+	if err := cp.exprs(e.Args); err != nil {
+		return err
+	}
+	if path, ok := calleePath(e.Callee); ok {
+		_, local := cp.resolve(path[0])
+		if !local || (len(path) == 1 && path[0] == "print") {
+			cp.emit(opCallPath, cp.constant(callSite{e, path}), len(e.Args))
+			return nil
+		}
+	}
+	if err := cp.expr(e.Callee); err != nil {
+		return err
+	}
+	cp.emit(opCall, cp.node(e), len(e.Args))
+	return nil
+}
+
+func isCompound(s *ast.AssignStatement) bool {
+	_, ok := compoundOps[s.Op]
+	return ok
+}
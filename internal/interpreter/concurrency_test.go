@@ -0,0 +1,214 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// within fails the test if f does not return in time, which here means
+// the interpreter lock was never released.
+func within(t *testing.T, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock: timed out waiting for the interpreter")
+	}
+}
+
+func TestRegisteredBuiltinCallsBack(t *testing.T) {
+	var out strings.Builder
+	in := New(Options{Output: &out})
+	in.Register("apply", func(args []any) (any, error) {
+		return args[0].(*Function).Call(int64(2))
+	})
+	within(t, func() {
+		if err := in.RunFile("test.os", "fn dbl(x) { return x * 2 }\nprint(apply(dbl))"); err != nil {
+			t.Error(err)
+		}
+	})
+	if out.String() != "4\n" {
+		t.Errorf("output %q, want %q", out.String(), "4\n")
+	}
+}
+
+// A registered builtin that blocks must not stop other handlers running.
+func TestRegisteredBuiltinDoesNotBlockHandlers(t *testing.T) {
+	in := New(Options{Output: io.Discard})
+	release := make(chan struct{})
+	in.Register("wait", func([]any) (any, error) {
+		<-release
+		return nil, nil
+	})
+	in.Register("release", func([]any) (any, error) {
+		close(release)
+		return nil, nil
+	})
+	mux := serve(t, in, `route("/wait", fn() { wait() })
+route("/release", fn() { release() })`)
+
+	within(t, func() {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/wait", nil))
+		}()
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/release", nil))
+		wg.Wait()
+	})
+}
+
+// Many concurrent requests run one handler that updates module state and
+// calls Go functions, which release the lock mid-handler. Every update
+// must land, and the run must be clean under the race detector.
+func TestConcurrentHandlers(t *testing.T) {
+	in := New(Options{Output: io.Discard})
+
+	var mu sync.Mutex
+	seen := map[int64]bool{}
+	in.Register("record", func(args []any) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[args[0].(int64)] = true
+		return nil, nil
+	})
+	in.SetVariable("lookup", func(n int) int {
+		time.Sleep(100 * time.Microsecond)
+		return n * 2
+	})
+	mux := serve(t, in, `let hits = 0
+let byPath = { "/count/a": 0, "/count/b": 0 }
+let doubled = []
+route("/count/", fn(w, r) {
+  let n = hits
+  hits += 1
+  let mine = hits
+  byPath[r.URL.Path] += 1
+  push(doubled, lookup(n))
+  record(mine)
+  w.Write(str(mine))
+})`)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	const workers, perWorker = 20, 25
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perWorker)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			path := "/count/a"
+			if i%2 == 1 {
+				path = "/count/b"
+			}
+			for j := 0; j < perWorker; j++ {
+				resp, err := http.Get(srv.URL + path)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != 200 || len(body) == 0 {
+					errs <- fmt.Errorf("%s: status %d, body %q", path, resp.StatusCode, body)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	const total = workers * perWorker
+	v, err := in.Eval(`[hits, byPath["/count/a"], byPath["/count/b"], len(doubled)]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := formatValue(v), fmt.Sprintf("[%d, %d, %d, %d]", total, total/2, total/2, total); got != want {
+		t.Errorf("state %s, want %s", got, want)
+	}
+	if len(seen) != total {
+		t.Errorf("record saw %d distinct hits, want %d", len(seen), total)
+	}
+}
+
+// Go code reading a script's array or object after the lock is released
+// must not see it change: one handler grows a shared object while others
+// hand it to Go functions that encode it. Each encoding must be a
+// consistent snapshot, and the run must be clean under the race detector.
+func TestSharedValuesPassedToGo(t *testing.T) {
+	in := New(Options{Output: io.Discard})
+	// encode pauses first so that a handler changing the value gets to
+	// run while the interpreter is unlocked.
+	encode := func(v any) string {
+		time.Sleep(200 * time.Microsecond)
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	}
+	in.SetVariable("encode", encode)
+	in.Register("inspect", func(args []any) (any, error) {
+		return encode(args[0]), nil
+	})
+	in.Register("fetch", func(args []any) (any, error) {
+		v, err := args[0].(*Function).Call()
+		return encode(v), err
+	})
+	mux := serve(t, in, `let shared = {n: 0, xs: []}
+route("/mutate", fn(w, r) {
+  push(shared.xs, shared.n)
+  shared.n += 1
+})
+route("/encode", fn(w, r) { w.Write(encode(shared)) })
+route("/inspect", fn(w, r) { w.Write(inspect(shared)) })
+route("/fetch", fn(w, r) { w.Write(fetch(fn() { return shared })) })`)
+
+	const rounds = 200
+	within(t, func() {
+		var wg sync.WaitGroup
+		for _, path := range []string{"/mutate", "/encode", "/inspect", "/fetch"} {
+			wg.Add(1)
+			go func(path string) {
+				defer wg.Done()
+				for i := 0; i < rounds; i++ {
+					rec := httptest.NewRecorder()
+					mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+					if path == "/mutate" {
+						continue
+					}
+					var got struct {
+						N  int   `json:"n"`
+						Xs []int `json:"xs"`
+					}
+					if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+						t.Errorf("%s: %v: %s", path, err, rec.Body)
+						return
+					}
+					if len(got.Xs) != got.N {
+						t.Errorf("%s: torn read: n is %d but xs has %d elements", path, got.N, len(got.Xs))
+						return
+					}
+				}
+			}(path)
+		}
+		wg.Wait()
+	})
+}
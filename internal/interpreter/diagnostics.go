@@ -0,0 +1,43 @@
+package interpreter
+
+import (
+	"errors"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+)
+
+// report prints err as one or more diagnostics. Loaded modules stay cached
+// after RunFile returns, so errors raised later, for example inside HTTP
+// handlers, still show the offending line.
+func (in *Interpreter) report(err error) {
+	for _, d := range diagnose(err) {
+		in.opts.Diagnostics.Print(in.sources[d.File], d)
+	}
+}
+
+// diagnose converts an error from parsing or execution into diagnostics.
+func diagnose(err error) []*diag.Diagnostic {
+	var syntax *syntaxError
+	if errors.As(err, &syntax) {
+		out := make([]*diag.Diagnostic, len(syntax.list))
+		for i, e := range syntax.list {
+			out[i] = &diag.Diagnostic{Kind: diag.Syntax, File: syntax.file, Line: e.Pos.Line, Column: e.Pos.Col, Message: e.Msg}
+		}
+		return out
+	}
+
+	d := &diag.Diagnostic{Kind: diag.Runtime, Message: err.Error()}
+	var osunErr *Error
+	var thrown *throwSignal
+	switch {
+	case errors.As(err, &osunErr):
+		d.Type, d.Message, d.File = osunErr.Kind, osunErr.Message, osunErr.File
+		d.Line, d.Column = osunErr.Pos.Line, osunErr.Pos.Col
+		d.Stack = osunErr.Trace
+	case errors.As(err, &thrown):
+		d.Message = "uncaught exception: " + formatValue(thrown.value)
+		d.Line, d.Column, d.File = thrown.pos.Line, thrown.pos.Col, thrown.file
+		d.Stack = thrown.trace
+	}
+	return []*diag.Diagnostic{d}
+}
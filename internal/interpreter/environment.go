@@ -0,0 +1,75 @@
+package interpreter
+
+import "fmt"
+
+// Environment is one lexical scope. Scopes nest: the module scope holds
+// top-level bindings, each function call gets a scope whose parent is the
+// scope the function was defined in, and every block ({ ... }, if/else
+// branches, loop bodies) gets a scope whose parent is the enclosing one.
+//
+// Shadowing rules:
+//   - `let` always declares in the current scope, hiding any binding with the
+//     same name in enclosing scopes until the current scope ends.
+//   - Declaring a name twice in the same scope replaces the earlier binding.
+//   - Plain assignment (`x = v`) updates the nearest enclosing binding and
+//     fails if the name was never declared.
+//   - `const` bindings cannot be assigned to or redeclared in the same scope,
+//     but an inner scope may shadow them with its own `let` or `const`.
+//   - Function parameters live in the function's scope, so a `let` of the
+//     same name directly in the function body replaces the parameter.
+type Environment struct {
+	vars   map[string]any
+	consts map[string]bool
+	parent *Environment
+}
+
+// NewEnvironment creates a scope nested inside parent (nil for the module scope).
+func NewEnvironment(parent *Environment) *Environment {
+	return &Environment{vars: map[string]any{}, parent: parent}
+}
+
+// Get resolves name in this scope or the nearest enclosing one.
+func (e *Environment) Get(name string) (any, bool) {
+	for env := e; env != nil; env = env.parent {
+		if val, ok := env.vars[name]; ok {
+			return val, true
+		}
+	}
+	return nil, false
+}
+
+// Define binds name in this scope.
+func (e *Environment) Define(name string, value any) {
+	e.vars[name] = value
+}
+
+// Declare binds name in this scope for a let or const statement. It fails if
+// name is already a constant in this scope.
+func (e *Environment) Declare(name string, value any, isConst bool) error {
+	if e.consts[name] {
+		return fmt.Errorf("cannot redeclare constant %s", name)
+	}
+	e.vars[name] = value
+	if isConst {
+		if e.consts == nil {
+			e.consts = map[string]bool{}
+		}
+		e.consts[name] = true
+	}
+	return nil
+}
+
+// Set updates the nearest existing binding of name. It fails if the name is
+// not declared in any enclosing scope or is bound by const.
+func (e *Environment) Set(name string, value any) error {
+	for env := e; env != nil; env = env.parent {
+		if _, ok := env.vars[name]; ok {
+			if env.consts[name] {
+				return fmt.Errorf("cannot assign to constant %s", name)
+			}
+			env.vars[name] = value
+			return nil
+		}
+	}
+	return fmt.Errorf("assignment to undeclared variable %s", name)
+}
@@ -0,0 +1,95 @@
+package interpreter
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShadowing(t *testing.T) {
+	runScriptTests(t, []scriptTest{
+		{name: "let in block", src: `let x = 1
+{
+  let x = 2
+  print(x)
+}
+print(x)`, out: "2\n1\n"},
+		{name: "let in branches", src: `let x = 1
+if x == 1 {
+  let x = "then"
+  print(x)
+} else {
+  let x = "else"
+}
+print(x)`, out: "then\n1\n"},
+		{name: "assignment in branch updates outer", src: `let x = 1
+if true { x = 5 }
+print(x)`, out: "5\n"},
+		{name: "redeclare replaces", src: `let x = 1
+let x = "one"
+print(x)`, out: "one\n"},
+		{name: "assign undeclared", src: `y = 2`,
+			errKind: KindReference, errMsg: "assignment to undeclared variable y"},
+		{name: "for-in captures each iteration", src: `let fs = []
+for i in 0..3 {
+  push(fs, fn() { return i })
+}
+for f in fs { print(f()) }`, out: "0\n1\n2\n"},
+		{name: "let in loop body captured per iteration", src: `let gs = []
+for k, v in ["a", "b"] {
+  let w = v + k
+  push(gs, fn() { return w })
+}
+print(gs[0](), gs[1]())`, out: "a0\nb1\n"},
+		{name: "const assign", src: `const c = 1
+c = 2`, errKind: KindReference, errMsg: "cannot assign to constant c"},
+		{name: "const assign from inner scope", src: `const c = 1
+fn f() { c = 2 }
+f()`, errKind: KindReference, errMsg: "cannot assign to constant c"},
+		{name: "const redeclare", src: `const c = 1
+let c = 2`, errKind: KindRuntime, errMsg: "cannot redeclare constant c"},
+		{name: "const shadowed in inner scope", src: `const c = 1
+{
+  let c = 2
+  c = 3
+  print(c)
+}
+if true { const c = 4
+  print(c) }
+print(c)`, out: "3\n4\n1\n"},
+		{name: "let replaces parameter", src: `fn f(a) {
+  let a = a + 1
+  return a
+}
+let a = 10
+print(f(1), a)`, out: "2\n10\n"},
+		{name: "function scope does not leak", src: `fn f() { let inner = 1 }
+f()
+print(inner)`, errKind: KindReference, errMsg: "undefined variable inner"},
+	})
+}
+
+// Each handler call gets its own scope: a let in one handler, or in an
+// earlier request to the same handler, is never visible to another.
+func TestHandlersDoNotShareLets(t *testing.T) {
+	var out strings.Builder
+	mux := serve(t, New(Options{Output: &out}), `let shared = 0
+route("/a", fn(w, r) {
+  let x = "a"
+  try { print(seen) } catch (e) { print(e.kind) }
+  let seen = x
+  shared += 1
+})
+route("/b", fn(w, r) {
+  try { print(x) } catch (e) { print(e.kind) }
+  let x = "b"
+  print(x, shared)
+})`)
+	for _, path := range []string{"/a", "/a", "/b"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	want := "ReferenceError\nReferenceError\nReferenceError\nb\n2\n"
+	if out.String() != want {
+		t.Errorf("output %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,126 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// Error kinds reported in Error.Kind.
+const (
+	KindError     = "Error" // default for error("...") and thrown values
+	KindRuntime   = "RuntimeError"
+	KindType      = "TypeError"
+	KindReference = "ReferenceError"
+	KindIndex     = "IndexError"
+	KindArith     = "ArithmeticError"
+	KindLimit     = "LimitError"
+	KindTimeout   = "TimeoutError" // a run or request outlived its deadline
+	KindCancel    = "CancelError"  // the caller cancelled the run
+	KindBuiltin   = "BuiltinError" // a Go error returned by a runtime builtin
+	KindImport    = "ImportError"
+)
+
+// Error is an Osun runtime error. It is both the Go error that unwinds the
+// interpreter and the value a catch block receives, exposing message, kind,
+// file, line and column to scripts.
+type Error struct {
+	Kind    string
+	Message string
+	Pos     token.Pos
+	File    string
+	Trace   []diag.Frame // script call stack where the error was raised
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Kind, e.Message)
+}
+
+func (e *Error) String() string {
+	return e.Kind + ": " + e.Message
+}
+
+// field returns the script-visible property name of the error.
+func (e *Error) field(name string) (any, bool) {
+	switch name {
+	case "message":
+		return e.Message, true
+	case "kind":
+		return e.Kind, true
+	case "line":
+		return int64(e.Pos.Line), true
+	case "column":
+		return int64(e.Pos.Col), true
+	case "file":
+		return e.File, true
+	}
+	return nil, false
+}
+
+// throwSignal carries a non-error value raised with `throw` up to the
+// nearest catch block.
+type throwSignal struct {
+	value any
+	pos   token.Pos
+	file  string
+	trace []diag.Frame
+}
+
+func (t *throwSignal) Error() string {
+	return fmt.Sprintf("%s: uncaught exception: %s", t.pos, formatValue(t.value))
+}
+
+// newError creates an Error of the given kind at node's position. Its file
+// and stack trace are filled in by annotate.
+func newError(kind string, node ast.Node, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...), Pos: node.Pos()}
+}
+
+// annotate records the running file and call stack on an Error that does
+// not have them yet. It runs as the error leaves the statement that raised
+// it, before any call frame is popped, so the stack is still the one in
+// effect where the error happened.
+func (th *thread) annotate(err error) error {
+	if e, ok := err.(*Error); ok && e.Trace == nil && e.Pos != (token.Pos{}) {
+		e.File, e.Trace = th.currentFile(), th.stackTrace(e.Pos)
+	}
+	return err
+}
+
+// runtimeError creates a generic RuntimeError at node's position.
+func runtimeError(node ast.Node, format string, args ...any) error {
+	return newError(KindRuntime, node, format, args...)
+}
+
+// wrapBuiltinError turns an error returned by Go code into a catchable
+// BuiltinError at node's position. Errors that already carry a script
+// location, or are control-flow signals, pass through unchanged.
+func wrapBuiltinError(node ast.Node, err error) error {
+	var osunErr *Error
+	var thrown *throwSignal
+	if errors.As(err, &osunErr) || errors.As(err, &thrown) {
+		return err
+	}
+	return newError(KindBuiltin, node, "%v", err)
+}
+
+// catchable reports whether err was raised by a failure or a throw, as
+// opposed to break, continue or return unwinding through a try block.
+func catchable(err error) bool {
+	switch err.(type) {
+	case *Error, *throwSignal:
+		return true
+	}
+	return false
+}
+
+// caughtValue is the value bound to the catch variable for err.
+func caughtValue(err error) any {
+	if t, ok := err.(*throwSignal); ok {
+		return t.value
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+)
+
+// Function is a user-defined Osun function value. It implements
+// runtime.Callable so that Go builtins can call back into scripts.
+type Function struct {
+	Name    string
+	decl    *ast.FunctionLiteral
+	closure *Environment // scope the function was defined in
+	module  *module      // file the function was defined in
+	caller  *thread      // thread that handed the function to Go, if any
+}
+
+// Call invokes the function with the given arguments and returns its
+// result. It is meant for Go code and runs on a thread of its own, which
+// continues the script's thread when that is waiting for the Go code
+// making the call. An array or object result is returned as a snapshot,
+// since the caller reads it after the interpreter is unlocked.
+func (f *Function) Call(args ...any) (any, error) {
+	in := f.module.in
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	th, cancel := in.callbackThread(f.caller, context.Background(), in.opts.Timeout)
+	defer cancel()
+	result, err := th.callUserFunction(f, args)
+	return snapshot(result, nil), err
+}
+
+// Arity returns the number of declared parameters.
+func (f *Function) Arity() int {
+	return len(f.decl.Params)
+}
+
+func (f *Function) String() string {
+	if f.Name == "" {
+		return "<fn>"
+	}
+	return fmt.Sprintf("<fn %s>", f.Name)
+}
+
+// returnSignal unwinds from a return statement to the enclosing call.
+type returnSignal struct {
+	value any
+}
+
+func (*returnSignal) Error() string { return "return outside of a function" }
+
+func (th *thread) callUserFunction(f *Function, args []any) (any, error) {
+	params := f.decl.Params
+	if len(args) > len(params) {
+		msg := fmt.Sprintf("%s expects %d arguments, got %d", f, len(params), len(args))
+		return nil, &Error{Kind: KindType, Message: msg, Pos: th.site}
+	}
+	if max := th.in.opts.MaxCallDepth; len(th.stack) >= max {
+		msg := fmt.Sprintf("maximum call depth of %d exceeded", max)
+		return nil, &Error{Kind: KindLimit, Message: msg, Pos: th.site}
+	}
+
+	name := f.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	th.pushCall(name)
+	prev := th.current
+	th.current = f.module
+	defer func() {
+		th.current = prev
+		th.popCall()
+	}()
+
+	if code := th.in.bytecode(f); code != nil {
+		return th.runChunk(code, f, args)
+	}
+
+	env := NewEnvironment(f.closure)
+	for i, p := range params {
+		if i < len(args) {
+			env.Define(p.Name, args[i])
+		} else {
+			env.Define(p.Name, nil)
+		}
+	}
+	err := th.executeBlock(f.decl.Body.Statements, env)
+	if ret, ok := err.(*returnSignal); ok {
+		return ret.value, nil
+	}
+	return nil, err
+}
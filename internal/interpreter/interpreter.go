@@ -1,453 +1,1351 @@
 package interpreter
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
-	"net/http"
+	"sync"
+	"time"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/diag"
 	"github.com/intellidevelopers/osun-lang/internal/runtime"
+	"github.com/intellidevelopers/osun-lang/internal/token"
 )
 
-var variables = map[string]any{}
+// errBreak and errContinue unwind from a break/continue statement to the
+// innermost enclosing loop.
+var (
+	errBreak    = errors.New("break outside of a loop")
+	errContinue = errors.New("continue outside of a loop")
+)
 
-// Run is the entry point for the interpreter.
-func Run(code string) {
-	rawLines := strings.Split(code, "\n")
-	lines := preprocessLines(rawLines)
-	executeBlock(lines, 0, len(lines))
+// DefaultMaxCallDepth is the call depth limit used when Options leaves
+// MaxCallDepth unset.
+const DefaultMaxCallDepth = 1000
+
+// Options configures a new Interpreter. The zero value is ready to use.
+type Options struct {
+	// Output receives print output. Defaults to os.Stdout.
+	Output io.Writer
+
+	// Diagnostics reports syntax and runtime errors from RunFile and from
+	// script callbacks run by Go code, such as HTTP handlers. Defaults to
+	// text diagnostics on Output.
+	Diagnostics *diag.Printer
+
+	// MaxLoopIterations limits how many times a single loop may iterate
+	// before it is stopped with a LimitError. Zero disables the guard.
+	MaxLoopIterations int
+
+	// MaxCallDepth limits how deeply script functions may recurse before
+	// the call fails instead of overflowing the Go stack.
+	MaxCallDepth int
+
+	// MaxSteps limits how many statements and loop iterations a single
+	// run, call or HTTP request may execute, including callbacks that Go
+	// functions it calls make into the script. Zero means no limit.
+	MaxSteps int
+
+	// Timeout bounds the wall-clock time of each RunFile, Eval and Call.
+	// Zero means no limit.
+	Timeout time.Duration
+
+	// RequestTimeout bounds the wall-clock time of a script handler
+	// serving one HTTP request. Zero means no limit beyond the request's
+	// own context.
+	RequestTimeout time.Duration
+
+	// NoBytecode runs every function on the tree-walking evaluator instead
+	// of compiling it for the bytecode VM, for comparison and debugging.
+	NoBytecode bool
 }
 
+// Interpreter runs Osun scripts. Each Interpreter has its own globals,
+// builtins, loaded modules and output, so several can run in one process.
+//
+// An Interpreter is safe for concurrent use. Script code runs under a
+// single lock that is released whenever the script calls into Go, so HTTP
+// handlers on different goroutines each run in their own scope, share
+// module state safely, and overlap while waiting on I/O.
+type Interpreter struct {
+	mu       sync.Mutex
+	opts     Options
+	globals  *Environment // host variables; each module's scope is a child
+	builtins map[string]*Builtin
 
+	modules map[string]*module      // loaded modules by absolute path
+	sources map[string]*diag.Source // text of every file read, for snippets
+	main    *module                 // the file passed to RunFile
+	units   map[string]*unit        // parsed and compiled files by absolute path
+}
 
-func SetVariable(name string, value interface{}) {
-	variables[name] = value
-}
-
-// preprocessLines splits "} else {" and trims empty lines but preserves structure.
-func preprocessLines(raw []string) []string {
-	var out []string
-	for _, l := range raw {
-		line := strings.TrimSpace(l)
-		if line == "" {
-			continue
-		}
-		if strings.Contains(line, "} else {") {
-			parts := strings.Split(line, "} else {")
-			left := strings.TrimSpace(parts[0])
-			if left != "" {
-				out = append(out, left+"}")
-			} else {
-				out = append(out, "}")
-			}
-			out = append(out, "else {")
-			if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
-				out = append(out, strings.TrimSpace(parts[1]))
-			}
-			continue
+// New creates an Interpreter with the standard builtins.
+func New(opts Options) *Interpreter {
+	if opts.Output == nil {
+		opts.Output = os.Stdout
+	}
+	if opts.Diagnostics == nil {
+		opts.Diagnostics = &diag.Printer{Out: opts.Output}
+	}
+	if opts.MaxCallDepth == 0 {
+		opts.MaxCallDepth = DefaultMaxCallDepth
+	}
+	return &Interpreter{
+		opts:     opts,
+		globals:  NewEnvironment(nil),
+		builtins: defaultBuiltins(),
+		modules:  map[string]*module{},
+		sources:  map[string]*diag.Source{},
+		units:    map[string]*unit{},
+	}
+}
+
+// RunFile runs code read from the named file; files it imports are
+// resolved relative to name. Any syntax or runtime error is reported
+// through Diagnostics and also returned.
+func (in *Interpreter) RunFile(name, code string) error {
+	return in.RunFileContext(context.Background(), name, code)
+}
+
+// RunFileContext is RunFile stopping with an error once ctx is done.
+func (in *Interpreter) RunFileContext(ctx context.Context, name, code string) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.modules = map[string]*module{}
+	in.sources = map[string]*diag.Source{}
+	path := ""
+	if name != "" {
+		if abs, err := filepath.Abs(name); err == nil {
+			path = abs
 		}
-		out = append(out, line)
 	}
-	return out
+	in.main = in.newModule(name, path, code)
+	if path != "" {
+		in.modules[path] = in.main
+	}
+	th, cancel := in.newThread(ctx, in.opts.Timeout)
+	defer cancel()
+	err := th.runModule(in.main, code)
+	if err != nil {
+		in.report(err)
+	}
+	return err
 }
 
-// interpreter/interpreter.go
-func GetVariable(name string) (any, bool) {
-	val, ok := variables[name]
-	return val, ok
-}
-
-
-// executeBlock runs lines[start:end)
-func executeBlock(lines []string, start, end int) {
-	for i := start; i < end; i++ {
-		line := strings.TrimSpace(lines[i])
-		if line == "" || strings.HasPrefix(line, "//") {
-			continue
-		}
-		if line == "{" || line == "}" {
-			continue
-		}
-
-		switch {
-		case strings.HasPrefix(line, "let "):
-			handleLet(line)
-		case strings.HasPrefix(line, "print(") && strings.HasSuffix(line, ")"):
-			handlePrint(line)
-		case strings.HasPrefix(line, "if "):
-			i = handleIfElse(lines, i)
-		case strings.HasPrefix(line, "server.Handle"):
-			handleServerHandle(line)
-		case strings.HasPrefix(line, "server.Listen"):
-			handleServerListen(line)
-		default:
-			if err := handleBuiltin(line); err != nil {
-				fmt.Println("❌ Unknown command:", line)
-			}
+// Eval runs src in the main module's scope, so it can use and add to the
+// variables of earlier RunFile and Eval calls. It returns the value of the
+// final statement when that is an expression, and nil otherwise.
+func (in *Interpreter) Eval(src string) (any, error) {
+	return in.EvalContext(context.Background(), src)
+}
+
+// EvalContext is Eval stopping with an error once ctx is done.
+func (in *Interpreter) EvalContext(ctx context.Context, src string) (any, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if in.main == nil {
+		in.main = in.newModule("<eval>", "", "")
+	}
+	m := in.newModule("<eval>", "", src)
+	m.env, m.exports = in.main.env, in.main.exports
+	u, err := in.load("", m.name, src)
+	if err != nil {
+		return nil, err
+	}
+	m.unit = u
+
+	th, cancel := in.newThread(ctx, in.opts.Timeout)
+	defer cancel()
+	th.current = m
+	stmts := u.prog.Statements
+	var last *ast.ExpressionStatement
+	if n := len(stmts); n > 0 {
+		if es, ok := stmts[n-1].(*ast.ExpressionStatement); ok {
+			last, stmts = es, stmts[:n-1]
 		}
 	}
+	if err := th.executeBlock(stmts, m.env); err != nil {
+		return nil, err
+	}
+	if last == nil {
+		return nil, nil
+	}
+	v, err := th.evalExpr(last.Expr, m.env)
+	return v, th.annotate(err)
 }
 
+// Call calls the script function bound to name in the main module. Go
+// arguments are converted as for values returned by builtins; the result
+// is an Osun value.
+func (in *Interpreter) Call(name string, args ...any) (any, error) {
+	return in.CallContext(context.Background(), name, args...)
+}
 
-// -------------------- Builtin Execution ------------------------
+// CallContext is Call stopping with an error once ctx is done.
+func (in *Interpreter) CallContext(ctx context.Context, name string, args ...any) (any, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	fn, ok := in.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined function %s", name)
+	}
+	vals := make([]any, len(args))
+	for i, a := range args {
+		vals[i] = fromGoValue(a)
+	}
+	th, cancel := in.newThread(ctx, in.opts.Timeout)
+	defer cancel()
+	if f, ok := fn.(*Function); ok {
+		return th.callUserFunction(f, vals)
+	}
+	result, err := th.callValue(fn, vals)
+	return result, th.annotate(err)
+}
+
+// Register makes a Go function callable from scripts as name. A
+// func([]any) (any, error) receives Osun values, like the standard
+// builtins; any other function is called through reflection with its
+// arguments and results converted. Either way fn runs with the
+// interpreter unlocked, so it may block without stalling other handlers
+// and may call script functions it is passed. Arrays and objects reach fn
+// as copies, so changing them does not change the script's values.
+func (in *Interpreter) Register(name string, fn any) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	if f, ok := fn.(func([]any) (any, error)); ok {
+		in.builtins[name] = &Builtin{Name: name, Fn: f, host: true}
+		return
+	}
+	in.globals.Define(name, fn)
+}
 
-func handleBuiltin(line string) error {
-	if !strings.Contains(line, "(") || !strings.HasSuffix(line, ")") {
-		return fmt.Errorf("not a function call")
+// SetVariable defines a variable visible to every module.
+func (in *Interpreter) SetVariable(name string, value interface{}) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	in.globals.Define(name, value)
+}
+
+// GetVariable looks a variable up in the main module, then in globals.
+func (in *Interpreter) GetVariable(name string) (any, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	return in.lookup(name)
+}
+
+func (in *Interpreter) lookup(name string) (any, bool) {
+	if in.main != nil {
+		return in.main.env.Get(name)
 	}
+	return in.globals.Get(name)
+}
 
-	fnPath := line[:strings.Index(line, "(")]
-	argsStr := line[strings.Index(line, "(")+1 : len(line)-1]
-	fnPath = strings.TrimSpace(fnPath)
-	args := parseArgs(argsStr)
+// -------------------- Package API ------------------------
 
-	parts := strings.Split(fnPath, ".")
-	if len(parts) == 1 {
-		sym := runtime.GetSymbol(parts[0])
-		if sym == nil {
-			return fmt.Errorf("symbol not found: %s", parts[0])
+// Default is the Interpreter behind the package-level functions.
+var Default = New(Options{})
+
+// Run runs code on the Default interpreter.
+func Run(code string) {
+	Default.RunFile("", code)
+}
+
+// RunFile runs a file on the Default interpreter.
+func RunFile(name, code string) error {
+	return Default.RunFile(name, code)
+}
+
+// SetVariable defines a global variable on the Default interpreter.
+func SetVariable(name string, value interface{}) {
+	Default.SetVariable(name, value)
+}
+
+// GetVariable reads a variable from the Default interpreter.
+func GetVariable(name string) (any, bool) {
+	return Default.GetVariable(name)
+}
+
+// -------------------- Execution ------------------------
+
+// executeBlock runs each statement in env, stopping at the first error.
+// Callers pass a fresh child environment when the block opens a new scope.
+func (th *thread) executeBlock(stmts []ast.Statement, env *Environment) error {
+	for _, stmt := range stmts {
+		if err := th.execStatement(stmt, env); err != nil {
+			return err
 		}
-		callFunction(sym, args)
+	}
+	return nil
+}
+
+func (th *thread) execStatement(stmt ast.Statement, env *Environment) error {
+	if err := th.step(stmt); err != nil {
+		return th.annotate(err)
+	}
+	return th.annotate(th.exec(stmt, env))
+}
+
+func (th *thread) exec(stmt ast.Statement, env *Environment) error {
+	switch s := stmt.(type) {
+	case *ast.LetStatement:
+		return th.handleLet(s, env)
+	case *ast.IfStatement:
+		return th.handleIfElse(s, env)
+	case *ast.WhileStatement:
+		return th.handleWhile(s, env)
+	case *ast.ForInStatement:
+		return th.handleForIn(s, env)
+	case *ast.BreakStatement:
+		return errBreak
+	case *ast.ContinueStatement:
+		return errContinue
+	case *ast.FunctionStatement:
+		env.Define(s.Name.Name, &Function{Name: s.Name.Name, decl: s.Func, closure: env, module: th.current})
 		return nil
-	} else if len(parts) == 2 {
-		group := runtime.GetSymbol(parts[0])
-		if groupMap, ok := group.(map[string]interface{}); ok {
-			fn := groupMap[parts[1]]
-			if fn == nil {
-				return fmt.Errorf("method not found: %s", parts[1])
+	case *ast.ReturnStatement:
+		return th.handleReturn(s, env)
+	case *ast.TryStatement:
+		return th.handleTry(s, env)
+	case *ast.ThrowStatement:
+		return th.handleThrow(s, env)
+	case *ast.ImportStatement:
+		return th.handleImport(s, env)
+	case *ast.ExportStatement:
+		return th.handleExport(s, env)
+	case *ast.AssignStatement:
+		return th.handleAssign(s, env)
+	case *ast.IncDecStatement:
+		return th.handleIncDec(s, env)
+	case *ast.BlockStatement:
+		return th.executeBlock(s.Statements, NewEnvironment(env))
+	case *ast.ExpressionStatement:
+		_, err := th.evalExpr(s.Expr, env)
+		return err
+	default:
+		return runtimeError(stmt, "unsupported statement %T", stmt)
+	}
+}
+
+// -------------------- Builtin Execution ------------------------
+
+func (th *thread) evalCall(call *ast.CallExpression, env *Environment) (any, error) {
+	args, err := th.evalArgs(call.Args, env)
+	if err != nil {
+		return nil, err
+	}
+	path, _ := calleePath(call.Callee)
+	return th.invoke(call, path, args, env)
+}
+
+// invoke completes a call whose arguments have been evaluated: print, a
+// runtime symbol path such as db.insert, or the callee evaluated in env,
+// which reports an undefined name as a ReferenceError. path is the callee
+// flattened by calleePath, or nil.
+func (th *thread) invoke(call *ast.CallExpression, path []string, args []any, env *Environment) (any, error) {
+	if path != nil {
+		if len(path) == 1 && path[0] == "print" {
+			th.handlePrint(args)
+			return nil, nil
+		}
+
+		if _, isVar := env.Get(path[0]); !isVar && th.in.builtins[path[0]] == nil && runtime.GetSymbol(path[0]) != nil {
+			th.site = call.Pos()
+			result, err := th.handleBuiltin(path, args)
+			if err != nil {
+				return nil, wrapBuiltinError(call, err)
 			}
-			callFunction(fn, args)
-			return nil
+			return result, nil
+		}
+	}
+
+	callee, err := th.evalExpr(call.Callee, env)
+	if err != nil {
+		return nil, err
+	}
+	return th.callAt(call, callee, args)
+}
+
+// callAt calls callee on behalf of call, which positions any error.
+func (th *thread) callAt(call *ast.CallExpression, callee any, args []any) (any, error) {
+	th.site = call.Pos()
+	if fn, ok := callee.(*Function); ok {
+		return th.callUserFunction(fn, args)
+	}
+	result, err := th.callValue(callee, args)
+	if err != nil {
+		return nil, wrapBuiltinError(call, err)
+	}
+	return result, nil
+}
+
+// callValue calls a builtin or Go function value.
+func (th *thread) callValue(callee any, args []any) (any, error) {
+	switch fn := callee.(type) {
+	case *Function:
+		return th.callUserFunction(fn, args)
+	case *Builtin:
+		if fn.host {
+			return th.callHost(fn, args)
+		}
+		return fn.Fn(args)
+	}
+	if reflect.ValueOf(callee).Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s is not a function", typeName(callee))
+	}
+	return th.callFunction(callee, args)
+}
+
+// calleePath flattens `a.b.c` into its dotted segments.
+func calleePath(expr ast.Expression) ([]string, bool) {
+	switch e := expr.(type) {
+	case *ast.Identifier:
+		return []string{e.Name}, true
+	case *ast.MemberExpression:
+		parent, ok := calleePath(e.Object)
+		if !ok {
+			return nil, false
 		}
+		return append(parent, e.Property.Name), true
 	}
-	return fmt.Errorf("invalid builtin path")
+	return nil, false
 }
 
-func parseArgs(argStr string) []interface{} {
-	if strings.TrimSpace(argStr) == "" {
-		return []interface{}{}
+// handleBuiltin calls a runtime symbol by its dotted path, such as print,
+// db.insert or a.b.c, looking up each segment after the first as a member.
+func (th *thread) handleBuiltin(parts []string, args []any) (any, error) {
+	val := runtime.GetSymbol(parts[0])
+	if val == nil {
+		return nil, fmt.Errorf("symbol not found: %s", parts[0])
 	}
-	argsRaw := splitArgs(argStr)
-	var args []interface{}
-	for _, a := range argsRaw {
-		args = append(args, evalExpr(a))
+	for i, name := range parts[1:] {
+		member, ok := lookupMember(val, name)
+		if !ok {
+			return nil, fmt.Errorf("%s has no member %s", strings.Join(parts[:i+1], "."), name)
+		}
+		val = member
 	}
-	return args
+	return th.callValue(val, args)
 }
 
-func splitArgs(s string) []string {
-	var args []string
-	var cur strings.Builder
-	inQuotes := false
-	for _, ch := range s {
-		if ch == '"' {
-			inQuotes = !inQuotes
+// lookupMember resolves obj.name: a field of an Osun object, an entry of a
+// string-keyed Go map such as a runtime symbol group, or a method or exported
+// field of any other Go value.
+func lookupMember(obj any, name string) (any, bool) {
+	switch t := obj.(type) {
+	case *Object:
+		return t.Get(name)
+	case map[string]interface{}:
+		v, ok := t[name]
+		return v, ok
+	case *Error:
+		return t.field(name)
+	case nil:
+		return nil, false
+	}
+
+	rv := reflect.ValueOf(obj)
+	if m := rv.MethodByName(name); m.IsValid() {
+		return m.Interface(), true
+	}
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, false
 		}
-		if ch == ',' && !inQuotes {
-			args = append(args, strings.TrimSpace(cur.String()))
-			cur.Reset()
-			continue
+		rv = rv.Elem()
+	}
+	if rv.Kind() == reflect.Struct {
+		if f, ok := rv.Type().FieldByName(name); ok && f.IsExported() {
+			return fromGoValue(rv.FieldByIndex(f.Index).Interface()), true
 		}
-		cur.WriteRune(ch)
 	}
-	if cur.Len() > 0 {
-		args = append(args, strings.TrimSpace(cur.String()))
+	return nil, false
+}
+
+func (th *thread) evalArgs(exprs []ast.Expression, env *Environment) ([]any, error) {
+	args := make([]any, 0, len(exprs))
+	for _, e := range exprs {
+		v, err := th.evalExpr(e, env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
 	}
-	return args
+	return args, nil
 }
 
-func callFunction(fn interface{}, args []interface{}) {
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// callFunction invokes a Go function through reflection. It returns the
+// function's first result converted to an Osun value; a non-nil trailing
+// error result is returned as the error instead. A Go function whose first
+// parameter is a context.Context receives the thread's context there, and
+// scripts pass only the remaining arguments.
+func (th *thread) callFunction(fn interface{}, args []interface{}) (any, error) {
 	fv := reflect.ValueOf(fn)
 	if fv.Kind() != reflect.Func {
-		fmt.Println("❌ not a function:", fn)
-		return
+		return nil, fmt.Errorf("not a function: %v", fn)
 	}
 
-	if len(args) != fv.Type().NumIn() {
-		fmt.Println("⚠️ argument mismatch for function")
+	var lead []reflect.Value
+	if ft := fv.Type(); ft.NumIn() > 0 && ft.In(0) == contextType {
+		lead = append(lead, reflect.ValueOf(&th.ctx).Elem())
+	}
+	argv, err := th.coerceArgs(fv.Type(), lead, args)
+	if err != nil {
+		return nil, err
 	}
+	out, err := th.callGo(fv, argv)
+	if err != nil {
+		return nil, err
+	}
+	ft := fv.Type()
+	if n := ft.NumOut(); n > 0 && ft.Out(n-1).Implements(errorType) {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return fromGoValue(out[0].Interface()), nil
+}
 
-	in := make([]reflect.Value, len(args))
-	for i, arg := range args {
-		in[i] = reflect.ValueOf(arg)
+// callGo calls a Go function with the interpreter unlocked, so handlers on
+// other goroutines can run script code while it blocks, for example on a
+// database query. A panic inside Go code becomes an ordinary error.
+func (th *thread) callGo(fv reflect.Value, args []reflect.Value) (out []reflect.Value, err error) {
+	err = th.unlocked(func() error {
+		out = fv.Call(args)
+		return nil
+	})
+	return out, err
+}
+
+// callHost calls a builtin added with Register, unlocked like callGo. The
+// builtin is given snapshots of its arguments, taken while still locked.
+func (th *thread) callHost(b *Builtin, args []any) (result any, err error) {
+	copies := map[any]any{}
+	snap := make([]any, len(args))
+	for i, a := range args {
+		snap[i] = copyValue(a, th, copies)
 	}
+	args = snap
+	err = th.unlocked(func() (err error) {
+		result, err = b.Fn(args)
+		return err
+	})
+	return result, err
+}
 
+// unlocked runs f with the interpreter unlocked and th waiting, turning a
+// panic into an error.
+func (th *thread) unlocked(f func() error) (err error) {
+	th.waiting = true
+	th.in.mu.Unlock()
+	defer func() {
+		th.in.mu.Lock()
+		th.waiting = false
+	}()
 	defer func() {
 		if r := recover(); r != nil {
-			fmt.Println("❌ runtime error:", r)
+			err = fmt.Errorf("panic: %v", r)
 		}
 	}()
-
-	fv.Call(in)
+	return f()
 }
 
 // -------------------- IF / ELSE HANDLING ------------------------
 
-func handleIfElse(lines []string, i int) int {
-	condExpr, blockStart := parseConditionBlock(lines, i)
-	blockEnd := findBlockEnd(lines, i)
+func (th *thread) handleIfElse(stmt *ast.IfStatement, env *Environment) error {
+	ok, err := th.evalCondition(stmt.Condition, env)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return th.executeBlock(stmt.Consequence.Statements, NewEnvironment(env))
+	}
+	if stmt.Alternative != nil {
+		return th.execStatement(stmt.Alternative, env)
+	}
+	return nil
+}
+
+// -------------------- LOOPS ------------------------
 
-	if evalCondition(condExpr) {
-		executeBlock(lines, blockStart+1, blockEnd)
-		i = blockEnd
-		if blockEnd+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[blockEnd+1]), "else") {
-			i = findBlockEnd(lines, blockEnd+1)
+func (th *thread) handleWhile(stmt *ast.WhileStatement, env *Environment) error {
+	for iterations := 1; ; iterations++ {
+		ok, err := th.evalCondition(stmt.Condition, env)
+		if err != nil {
+			return err
 		}
-	} else {
-		blockEnd := findBlockEnd(lines, i)
-		if blockEnd+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[blockEnd+1]), "else") {
-			elseStart := blockEnd + 1
-			elseEnd := findBlockEnd(lines, elseStart)
-			executeBlock(lines, elseStart+1, elseEnd)
-			i = elseEnd
-		} else {
-			i = blockEnd
+		if !ok {
+			return nil
+		}
+		if err := th.checkLoopGuard(stmt, iterations); err != nil {
+			return err
+		}
+		err = th.executeBlock(stmt.Body.Statements, NewEnvironment(env))
+		if err == errBreak {
+			return nil
+		}
+		if err != nil && err != errContinue {
+			return err
 		}
 	}
-	return i
+}
+
+func (th *thread) handleForIn(stmt *ast.ForInStatement, env *Environment) error {
+	iterations := 0
+	return th.iterate(stmt, env, func(key, value any) (bool, error) {
+		iterations++
+		if err := th.checkLoopGuard(stmt, iterations); err != nil {
+			return false, err
+		}
+		// Each iteration gets its own scope so closures capture that
+		// iteration's values.
+		body := NewEnvironment(env)
+		if stmt.Key != nil {
+			body.Define(stmt.Key.Name, key)
+		}
+		body.Define(stmt.Value.Name, value)
+		err := th.executeBlock(stmt.Body.Statements, body)
+		if err == errBreak {
+			return false, nil
+		}
+		if err != nil && err != errContinue {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// iterate calls yield with each key/value pair of the loop's iterable until
+// yield returns false or an error. In the single-variable form of a for-in
+// loop over a map, the key is bound instead of the value.
+func (th *thread) iterate(stmt *ast.ForInStatement, env *Environment, yield func(key, value any) (bool, error)) error {
+	if r, ok := stmt.Iterable.(*ast.RangeExpression); ok {
+		start, err := th.evalExpr(r.Start, env)
+		if err != nil {
+			return err
+		}
+		end, err := th.evalExpr(r.End, env)
+		if err != nil {
+			return err
+		}
+		return iterateRange(r, start, end, yield)
+	}
+
+	coll, err := th.evalExpr(stmt.Iterable, env)
+	if err != nil {
+		return err
+	}
+	return iterateValue(stmt, coll, yield)
+}
+
+// iterateValue is iterate over an already evaluated iterable.
+func iterateValue(stmt *ast.ForInStatement, coll any, yield func(key, value any) (bool, error)) error {
+	if arr, ok := coll.(*Array); ok {
+		// Iterate over a snapshot so the body may push to the array.
+		elems := append([]any(nil), arr.Elements...)
+		for i, v := range elems {
+			if more, err := yield(int64(i), v); !more || err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if obj, ok := coll.(*Object); ok {
+		keys := append([]string(nil), obj.Keys()...)
+		for _, k := range keys {
+			value, _ := obj.Get(k)
+			if stmt.Key == nil {
+				value = k
+			}
+			if more, err := yield(k, value); !more || err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if s, ok := coll.(string); ok {
+		i := 0
+		for _, ch := range s {
+			if more, err := yield(int64(i), string(ch)); !more || err != nil {
+				return err
+			}
+			i++
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(coll)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			if more, err := yield(int64(i), rv.Index(i).Interface()); !more || err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			key, value := k.Interface(), rv.MapIndex(k).Interface()
+			if stmt.Key == nil {
+				value = key
+			}
+			if more, err := yield(key, value); !more || err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return newError(KindType, stmt.Iterable, "cannot iterate over %s", typeName(coll))
+}
+
+func iterateRange(r *ast.RangeExpression, start, end any, yield func(key, value any) (bool, error)) error {
+	from, ok1 := start.(int64)
+	to, ok2 := end.(int64)
+	if !ok1 || !ok2 {
+		return newError(KindType, r, "range bounds must be ints, got %s..%s", typeName(start), typeName(end))
+	}
+	for i, n := int64(0), from; n < to; i, n = i+1, n+1 {
+		if more, err := yield(i, n); !more || err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (th *thread) checkLoopGuard(loop ast.Statement, iterations int) error {
+	if err := th.step(loop); err != nil {
+		return err
+	}
+	if max := th.in.opts.MaxLoopIterations; max > 0 && iterations > max {
+		return newError(KindLimit, loop, "loop exceeded %d iterations", max)
+	}
+	return nil
 }
 
 // -------------------- Core Evaluators ------------------------
 
-func handleLet(line string) {
-	rest := strings.TrimSpace(strings.TrimPrefix(line, "let"))
-	parts := strings.SplitN(rest, "=", 2)
-	if len(parts) != 2 {
-		fmt.Println("Syntax error in let:", line)
-		return
+func (th *thread) handleLet(stmt *ast.LetStatement, env *Environment) error {
+	val, err := th.evalExpr(stmt.Value, env)
+	if err != nil {
+		return err
+	}
+	if err := env.Declare(stmt.Name.Name, val, stmt.Const); err != nil {
+		return runtimeError(stmt, "%v", err)
 	}
-	name := strings.TrimSpace(parts[0])
-	valueExpr := strings.TrimSpace(parts[1])
-	val := evalExpr(valueExpr)
-	variables[name] = val
+	return nil
 }
 
-func handlePrint(line string) {
-	inside := strings.TrimSpace(line[len("print(") : len(line)-1])
-	val := evalExpr(inside)
-	if val != nil {
-		fmt.Println(formatValue(val))
+// handleAssign runs plain (=) and compound (+= -= *= /=) assignment.
+func (th *thread) handleAssign(stmt *ast.AssignStatement, env *Environment) error {
+	ref, err := th.resolveTarget(stmt.Target, env)
+	if err != nil {
+		return err
+	}
+	val, err := th.evalExpr(stmt.Value, env)
+	if err != nil {
+		return err
+	}
+	if _, ok := compoundOps[stmt.Op]; ok {
+		cur, err := ref.get()
+		if err != nil {
+			return err
+		}
+		if val, err = applyUpdate(stmt, cur, val); err != nil {
+			return err
+		}
 	}
+	return ref.set(val)
 }
 
-// -------------------- HTTP SERVER HANDLERS ------------------------
+// compoundOps maps each compound assignment operator to its binary operator.
+var compoundOps = map[token.Kind]token.Kind{
+	token.PLUS_ASSIGN:  token.PLUS,
+	token.MINUS_ASSIGN: token.MINUS,
+	token.STAR_ASSIGN:  token.STAR,
+	token.SLASH_ASSIGN: token.SLASH,
+}
 
-func handleServerHandle(line string) {
-	// Example: server.Handle("GET", "/hello", func(){ print("hi") })
-	parts := strings.SplitN(line, "(", 2)
-	argsStr := strings.TrimSuffix(parts[1], ")")
-	args := parseArgs(argsStr)
-	if len(args) < 3 {
-		fmt.Println("❌ server.Handle requires 3 arguments")
-		return
+// handleIncDec runs x++ and x--.
+func (th *thread) handleIncDec(stmt *ast.IncDecStatement, env *Environment) error {
+	ref, err := th.resolveTarget(stmt.Target, env)
+	if err != nil {
+		return err
+	}
+	cur, err := ref.get()
+	if err != nil {
+		return err
+	}
+	next, err := applyUpdate(stmt, cur, nil)
+	if err != nil {
+		return err
 	}
+	return ref.set(next)
+}
 
-	serverVar, ok := variables["server"]
-	if !ok {
-		fmt.Println("❌ server variable not found")
-		return
+// applyUpdate computes the new value of the target of a compound
+// assignment from its current value and the right-hand side, or of an
+// x++ or x-- statement from its current value alone.
+func applyUpdate(stmt ast.Statement, cur, val any) (any, error) {
+	switch s := stmt.(type) {
+	case *ast.IncDecStatement:
+		if !isNumber(cur) {
+			return nil, newError(KindType, s, "cannot apply %s to %s", s.Op, typeName(cur))
+		}
+		op := token.PLUS
+		if s.Op == token.DEC {
+			op = token.MINUS
+		}
+		return evalArithmetic(s, op, cur, int64(1))
+	case *ast.AssignStatement:
+		return applyBinary(s, compoundOps[s.Op], cur, val)
 	}
-	server, ok := serverVar.(*runtime.OsunServer)
-	if !ok {
-		fmt.Println("❌ server variable invalid")
-		return
+	return nil, runtimeError(stmt, "unsupported update %T", stmt)
+}
+
+// lvalue is an assignment target whose object and index, if any, have
+// already been evaluated, so compound assignment evaluates them only once.
+type lvalue struct {
+	get func() (any, error)
+	set func(any) error
+}
+
+func (th *thread) resolveTarget(target ast.Expression, env *Environment) (*lvalue, error) {
+	switch t := target.(type) {
+	case *ast.Identifier:
+		return &lvalue{
+			get: func() (any, error) { return th.evalIdentifier(t, env) },
+			set: func(v any) error {
+				if err := env.Set(t.Name, v); err != nil {
+					return newError(KindReference, t, "%v", err)
+				}
+				return nil
+			},
+		}, nil
+	case *ast.IndexExpression:
+		obj, err := th.evalExpr(t.Object, env)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := th.evalExpr(t.Index, env)
+		if err != nil {
+			return nil, err
+		}
+		return &lvalue{
+			get: func() (any, error) { return indexValue(t, obj, idx) },
+			set: func(v any) error { return setIndex(t, obj, idx, v) },
+		}, nil
+	case *ast.MemberExpression:
+		obj, err := th.evalExpr(t.Object, env)
+		if err != nil {
+			return nil, err
+		}
+		o, ok := obj.(*Object)
+		if !ok {
+			return nil, newError(KindType, t, "cannot set field %q on %s", t.Property.Name, typeName(obj))
+		}
+		return &lvalue{
+			get: func() (any, error) {
+				v, _ := o.Get(t.Property.Name)
+				return v, nil
+			},
+			set: func(v any) error {
+				o.Set(t.Property.Name, v)
+				return nil
+			},
+		}, nil
 	}
+	return nil, runtimeError(target, "cannot assign to %T", target)
+}
 
-	method, ok1 := args[0].(string)
-	path, ok2 := args[1].(string)
-	handlerFunc, ok3 := args[2].(func())
-	if !ok1 || !ok2 || !ok3 {
-		fmt.Println("❌ invalid arguments for server.Handle")
-		return
+func setIndex(target *ast.IndexExpression, obj, idx, val any) error {
+	switch t := obj.(type) {
+	case *Array:
+		i, err := toIndex(idx, len(t.Elements))
+		if err != nil {
+			return newError(KindIndex, target, "%v", err)
+		}
+		t.Elements[i] = val
+		return nil
+	case *Object:
+		key, ok := idx.(string)
+		if !ok {
+			return newError(KindType, target, "object keys must be strings, got %s", typeName(idx))
+		}
+		t.Set(key, val)
+		return nil
 	}
+	return newError(KindType, target, "cannot assign to an element of %s", typeName(obj))
+}
 
-	// Wrap the interpreter func into http.HandlerFunc
-	server.Handle(method, path, func(w http.ResponseWriter, r *http.Request) {
-		// This executes the interpreter-level closure
-		handlerFunc()
-	})
+func (th *thread) handleReturn(stmt *ast.ReturnStatement, env *Environment) error {
+	ret := &returnSignal{}
+	if stmt.Value != nil {
+		val, err := th.evalExpr(stmt.Value, env)
+		if err != nil {
+			return err
+		}
+		ret.value = val
+	}
+	return ret
 }
 
+// -------------------- TRY / CATCH ------------------------
 
-func handleServerListen(line string) {
-	serverVar, ok := variables["server"]
-	if !ok {
-		fmt.Println("❌ server variable not found")
-		return
+// handleTry runs the try block, hands a thrown value or runtime error to
+// the catch block, and always runs finally. break, continue and return pass
+// through untouched; an error raised by finally replaces any earlier one.
+func (th *thread) handleTry(stmt *ast.TryStatement, env *Environment) error {
+	err := th.executeBlock(stmt.Body.Statements, NewEnvironment(env))
+	if err != nil && stmt.Catch != nil && catchable(err) {
+		catchEnv := NewEnvironment(env)
+		if stmt.CatchParam != nil {
+			catchEnv.Define(stmt.CatchParam.Name, caughtValue(err))
+		}
+		err = th.executeBlock(stmt.Catch.Statements, catchEnv)
 	}
-	server, ok := serverVar.(*runtime.OsunServer)
-	if !ok {
-		fmt.Println("❌ server variable invalid")
-		return
+	if stmt.Finally != nil {
+		if ferr := th.executeBlock(stmt.Finally.Statements, NewEnvironment(env)); ferr != nil {
+			return ferr
+		}
 	}
+	return err
+}
 
-	server.Listen()
+// handleThrow raises a value. Throwing an error object re-raises it; an
+// error created by error() takes the throw site as its location.
+func (th *thread) handleThrow(stmt *ast.ThrowStatement, env *Environment) error {
+	val, err := th.evalExpr(stmt.Value, env)
+	if err != nil {
+		return err
+	}
+	return th.raise(stmt, val)
+}
+
+// raise throws val from stmt.
+func (th *thread) raise(stmt *ast.ThrowStatement, val any) error {
+	if e, ok := val.(*Error); ok {
+		if e.Pos == (token.Pos{}) {
+			e.Pos, e.File, e.Trace = stmt.Pos(), th.currentFile(), th.stackTrace(stmt.Pos())
+		}
+		return e
+	}
+	return &throwSignal{value: val, pos: stmt.Pos(), file: th.currentFile(), trace: th.stackTrace(stmt.Pos())}
+}
+
+func (th *thread) handlePrint(args []any) {
+	for _, val := range args {
+		fmt.Fprintln(th.in.opts.Output, formatValue(val))
+	}
 }
 
 // -------------------- Expression Evaluators ------------------------
 
-func evalCondition(expr string) bool {
-	expr = strings.TrimSpace(expr)
-	ops := []string{">=", "<=", "==", "!=", ">", "<"}
-	for _, op := range ops {
-		if strings.Contains(expr, op) {
-			parts := strings.SplitN(expr, op, 2)
-			left := strings.TrimSpace(parts[0])
-			right := strings.TrimSpace(parts[1])
-			lv := evalExpr(left)
-			rv := evalExpr(right)
-			return compareValues(lv, rv, op)
-		}
+func (th *thread) evalCondition(expr ast.Expression, env *Environment) (bool, error) {
+	v, err := th.evalExpr(expr, env)
+	if err != nil {
+		return false, err
 	}
-	v := evalExpr(expr)
+	return isTruthy(v), nil
+}
+
+// isTruthy reports whether v counts as true in a condition: false, null,
+// 0 and "" are false, everything else is true.
+func isTruthy(v any) bool {
 	switch t := v.(type) {
+	case nil:
+		return false
 	case bool:
 		return t
+	case int64:
+		return t != 0
 	case float64:
 		return t != 0
 	case string:
 		return t != ""
 	default:
-		return false
+		return true
 	}
 }
 
-func evalExpr(expr string) any {
-	expr = strings.TrimSpace(expr)
-	if expr == "" {
-		return nil
+func (th *thread) evalExpr(expr ast.Expression, env *Environment) (any, error) {
+	switch e := expr.(type) {
+	case *ast.StringLiteral:
+		return e.Value, nil
+	case *ast.NumberLiteral:
+		return e.Value, nil
+	case *ast.TemplateLiteral:
+		var sb strings.Builder
+		for _, part := range e.Parts {
+			v, err := th.evalExpr(part, env)
+			if err != nil {
+				return nil, err
+			}
+			sb.WriteString(formatValue(v))
+		}
+		return sb.String(), nil
+	case *ast.BooleanLiteral:
+		return e.Value, nil
+	case *ast.NullLiteral:
+		return nil, nil
+	case *ast.Identifier:
+		return th.evalIdentifier(e, env)
+	case *ast.UnaryExpression:
+		return th.evalUnary(e, env)
+	case *ast.BinaryExpression:
+		return th.evalBinary(e, env)
+	case *ast.CallExpression:
+		return th.evalCall(e, env)
+	case *ast.MemberExpression:
+		return th.evalMember(e, env)
+	case *ast.ArrayLiteral:
+		elems, err := th.evalArgs(e.Elements, env)
+		if err != nil {
+			return nil, err
+		}
+		return NewArray(elems...), nil
+	case *ast.ObjectLiteral:
+		obj := NewObject()
+		for i, key := range e.Keys {
+			v, err := th.evalExpr(e.Values[i], env)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(key, v)
+		}
+		return obj, nil
+	case *ast.IndexExpression:
+		return th.evalIndex(e, env)
+	case *ast.FunctionLiteral:
+		return &Function{Name: e.Name, decl: e, closure: env, module: th.current}, nil
+	default:
+		return nil, runtimeError(expr, "unsupported expression %T", expr)
 	}
+}
 
-	if strings.HasPrefix(expr, "\"") && strings.HasSuffix(expr, "\"") {
-		return strings.Trim(expr, "\"")
+func (th *thread) evalIdentifier(id *ast.Identifier, env *Environment) (any, error) {
+	if v, ok := env.Get(id.Name); ok {
+		return v, nil
+	}
+	if b, ok := th.in.builtins[id.Name]; ok {
+		return b, nil
 	}
+	if sym := runtime.GetSymbol(id.Name); sym != nil {
+		return sym, nil
+	}
+	return nil, newError(KindReference, id, "undefined variable %s", id.Name)
+}
 
-	if v, ok := variables[expr]; ok {
-		return v
+func (th *thread) evalIndex(e *ast.IndexExpression, env *Environment) (any, error) {
+	obj, err := th.evalExpr(e.Object, env)
+	if err != nil {
+		return nil, err
 	}
+	idx, err := th.evalExpr(e.Index, env)
+	if err != nil {
+		return nil, err
+	}
+	return indexValue(e, obj, idx)
+}
 
-	if strings.Contains(expr, "+") {
-		parts := splitByPlus(expr)
-		var result strings.Builder
-		for _, p := range parts {
-			val := evalExpr(p)
-			result.WriteString(fmt.Sprintf("%v", val))
+func indexValue(e *ast.IndexExpression, obj, idx any) (any, error) {
+	switch t := obj.(type) {
+	case *Array:
+		i, err := toIndex(idx, len(t.Elements))
+		if err != nil {
+			return nil, newError(KindIndex, e, "%v", err)
+		}
+		return t.Elements[i], nil
+	case string:
+		runes := []rune(t)
+		i, err := toIndex(idx, len(runes))
+		if err != nil {
+			return nil, newError(KindIndex, e, "%v", err)
+		}
+		return string(runes[i]), nil
+	case *Object:
+		key, ok := idx.(string)
+		if !ok {
+			return nil, newError(KindType, e, "object keys must be strings, got %s", typeName(idx))
 		}
-		return result.String()
+		v, _ := t.Get(key)
+		return v, nil
 	}
+	return nil, newError(KindType, e, "cannot index %s", typeName(obj))
+}
 
-	if f, err := strconv.ParseFloat(expr, 64); err == nil {
-		return f
+func (th *thread) evalBinary(e *ast.BinaryExpression, env *Environment) (any, error) {
+	if e.Op == token.AND || e.Op == token.OR {
+		return th.evalLogical(e, env)
 	}
 
-	return expr
+	lv, err := th.evalExpr(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	rv, err := th.evalExpr(e.Right, env)
+	if err != nil {
+		return nil, err
+	}
+	return applyBinary(e, e.Op, lv, rv)
 }
 
-func splitByPlus(expr string) []string {
-	var parts []string
-	var cur strings.Builder
-	inQuotes := false
-	for _, ch := range expr {
-		if ch == '"' {
-			inQuotes = !inQuotes
-		}
-		if ch == '+' && !inQuotes {
-			parts = append(parts, strings.TrimSpace(cur.String()))
-			cur.Reset()
-			continue
+// applyBinary applies a non-logical binary operator to two values; node is
+// used for error positions.
+func applyBinary(node ast.Node, op token.Kind, lv, rv any) (any, error) {
+	switch op {
+	case token.PLUS:
+		if isNumber(lv) && isNumber(rv) {
+			return evalArithmetic(node, op, lv, rv)
 		}
-		cur.WriteRune(ch)
+		return formatValue(lv) + formatValue(rv), nil
+	case token.MINUS, token.STAR, token.SLASH, token.PERCENT:
+		return evalArithmetic(node, op, lv, rv)
+	case token.EQ, token.NEQ, token.LT, token.LTE, token.GT, token.GTE:
+		return compareValues(node, op, lv, rv)
 	}
-	if cur.Len() > 0 {
-		parts = append(parts, strings.TrimSpace(cur.String()))
+	return nil, runtimeError(node, "unsupported operator %s", op)
+}
+
+// evalLogical evaluates && and ||, skipping the right operand when the left
+// one already decides the result.
+func (th *thread) evalLogical(e *ast.BinaryExpression, env *Environment) (any, error) {
+	left, err := th.evalCondition(e.Left, env)
+	if err != nil {
+		return nil, err
+	}
+	if e.Op == token.AND && !left {
+		return false, nil
 	}
-	return parts
+	if e.Op == token.OR && left {
+		return true, nil
+	}
+	return th.evalCondition(e.Right, env)
 }
 
-// -------------------- Utilities ------------------------
+func (th *thread) evalUnary(e *ast.UnaryExpression, env *Environment) (any, error) {
+	v, err := th.evalExpr(e.Operand, env)
+	if err != nil {
+		return nil, err
+	}
+	return applyUnary(e, v)
+}
 
-func parseConditionBlock(lines []string, idx int) (string, int) {
-	line := strings.TrimSpace(lines[idx])
-	if strings.Contains(line, "{") {
-		start := strings.Index(line, "if") + 2
-		open := strings.Index(line, "{")
-		return strings.TrimSpace(line[start:open]), idx
-	}
-	cond := strings.TrimSpace(strings.TrimPrefix(line, "if"))
-	return cond, idx + 1
-}
-
-func findBlockEnd(lines []string, start int) int {
-	depth := 0
-	for i := start; i < len(lines); i++ {
-		for _, ch := range lines[i] {
-			if ch == '{' {
-				depth++
-			} else if ch == '}' {
-				depth--
-				if depth == 0 {
-					return i
-				}
+// applyUnary applies a unary operator to an evaluated operand.
+func applyUnary(e *ast.UnaryExpression, v any) (any, error) {
+	switch e.Op {
+	case token.NOT:
+		return !isTruthy(v), nil
+	case token.MINUS:
+		switch n := v.(type) {
+		case int64:
+			if n == math.MinInt64 {
+				return nil, newError(KindArith, e, "%v", errOverflow)
 			}
+			return -n, nil
+		case float64:
+			return -n, nil
 		}
+		return nil, newError(KindType, e, "cannot negate %s", typeName(v))
+	}
+	return nil, runtimeError(e, "unsupported operator %s", e.Op)
+}
+
+// evalArithmetic applies + - * / % to two numbers. Two ints give an int;
+// otherwise both operands are converted to float.
+func evalArithmetic(node ast.Node, op token.Kind, lv, rv any) (any, error) {
+	if !isNumber(lv) || !isNumber(rv) {
+		return nil, newError(KindType, node, "cannot apply %s to %s and %s", op, typeName(lv), typeName(rv))
+	}
+	li, lok := lv.(int64)
+	ri, rok := rv.(int64)
+	if lok && rok {
+		n, err := intArith(op, li, ri)
+		if err != nil {
+			return nil, newError(KindArith, node, "%v", err)
+		}
+		return n, nil
+	}
+	lf, _ := toFloat(lv)
+	rf, _ := toFloat(rv)
+	f, err := floatArith(op, lf, rf)
+	if err != nil {
+		return nil, newError(KindArith, node, "%v", err)
 	}
-	return len(lines) - 1
+	return f, nil
 }
 
-func compareValues(a, b any, op string) bool {
-	af, aok := toFloat(a)
-	bf, bok := toFloat(b)
-	if aok && bok {
-		switch op {
-		case ">":
-			return af > bf
-		case "<":
-			return af < bf
-		case ">=":
-			return af >= bf
-		case "<=":
-			return af <= bf
-		case "==":
-			return af == bf
-		case "!=":
-			return af != bf
+func (th *thread) evalMember(e *ast.MemberExpression, env *Environment) (any, error) {
+	obj, err := th.evalExpr(e.Object, env)
+	if err != nil {
+		return nil, err
+	}
+	return memberValue(e, obj)
+}
+
+// memberValue reads the member e names from an evaluated object.
+func memberValue(e *ast.MemberExpression, obj any) (any, error) {
+	// Missing object fields read as null.
+	if o, ok := obj.(*Object); ok {
+		v, _ := o.Get(e.Property.Name)
+		return v, nil
+	}
+	if v, ok := lookupMember(obj, e.Property.Name); ok {
+		return v, nil
+	}
+	if path, ok := calleePath(e.Object); ok {
+		return nil, newError(KindReference, e, "%s has no member %s", strings.Join(path, "."), e.Property.Name)
+	}
+	return nil, newError(KindReference, e, "%s has no member %s", typeName(obj), e.Property.Name)
+}
+
+// -------------------- Utilities ------------------------
+
+// compareValues applies a comparison operator. Numbers compare by value
+// whatever their type and strings compare byte-wise; ordering any other
+// pair of values is a TypeError. node is used for error positions.
+func compareValues(node ast.Node, op token.Kind, a, b any) (bool, error) {
+	switch op {
+	case token.EQ:
+		return equalValues(a, b), nil
+	case token.NEQ:
+		return !equalValues(a, b), nil
+	}
+
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			// Compare ints exactly; converting large ones to float loses precision.
+			return orderHolds(op, cmp.Compare(ai, bi)), nil
+		}
+	}
+	var c int
+	if isNumber(a) && isNumber(b) {
+		af, _ := toFloat(a)
+		bf, _ := toFloat(b)
+		if math.IsNaN(af) || math.IsNaN(bf) {
+			return false, nil
 		}
+		c = cmp.Compare(af, bf)
+	} else if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		if !ok {
+			return false, newError(KindType, node, "cannot compare %s %s %s", typeName(a), op, typeName(b))
+		}
+		c = strings.Compare(as, bs)
+	} else {
+		return false, newError(KindType, node, "cannot compare %s %s %s", typeName(a), op, typeName(b))
 	}
-	as := fmt.Sprintf("%v", a)
-	bs := fmt.Sprintf("%v", b)
+	return orderHolds(op, c), nil
+}
+
+// orderHolds reports whether an ordering operator accepts the result c of
+// comparing its operands.
+func orderHolds(op token.Kind, c int) bool {
 	switch op {
-	case "==":
-		return as == bs
-	case "!=":
-		return as != bs
-	default:
+	case token.LT:
+		return c < 0
+	case token.LTE:
+		return c <= 0
+	case token.GT:
+		return c > 0
+	}
+	return c >= 0
+}
+
+// equalValues implements ==. Numbers are equal when their values are, so
+// 1 == 1.0; any other values are equal only when they have the same type
+// and value, and arrays, objects and functions only when they are the same
+// one. A number never equals a string.
+func equalValues(a, b any) bool {
+	if isNumber(a) && isNumber(b) {
+		if ai, ok := a.(int64); ok {
+			if bi, ok := b.(int64); ok {
+				return ai == bi
+			}
+		}
+		af, _ := toFloat(a)
+		bf, _ := toFloat(b)
+		return af == bf
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || !ta.Comparable() {
 		return false
 	}
+	return a == b
 }
 
 func toFloat(v any) (float64, bool) {
 	switch n := v.(type) {
 	case float64:
 		return n, true
-	case int:
+	case int64:
 		return float64(n), true
-	case string:
-		if f, err := strconv.ParseFloat(n, 64); err == nil {
-			return f, true
-		}
 	}
 	return 0, false
 }
 
+// typeName describes a value's Osun type for error messages.
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case *Function, *Builtin:
+		return "function"
+	case *Array:
+		return "array"
+	case *Object:
+		return "object"
+	case *Error:
+		return "error"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
 func formatValue(v any) string {
 	switch t := v.(type) {
 	case nil:
-		return "<nil>"
+		return "null"
+	case int64:
+		return strconv.FormatInt(t, 10)
 	case float64:
-		if t == float64(int64(t)) {
-			return fmt.Sprintf("%d", int64(t))
-		}
-		return fmt.Sprintf("%v", t)
+		return formatFloat(t)
+	case *Error:
+		return t.String()
 	default:
 		return fmt.Sprintf("%v", t)
 	}
@@ -0,0 +1,239 @@
+package interpreter
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+	"github.com/intellidevelopers/osun-lang/internal/runtime"
+)
+
+// run runs src as a file and returns what it printed.
+func run(src string, opts Options) (string, error) {
+	return runWith(src, opts, nil)
+}
+
+// runWith is run with vars defined in the interpreter first.
+func runWith(src string, opts Options, vars map[string]any) (string, error) {
+	var out strings.Builder
+	opts.Output = &out
+	opts.Diagnostics = &diag.Printer{Out: io.Discard}
+	in := New(opts)
+	for name, v := range vars {
+		in.SetVariable(name, v)
+	}
+	err := in.RunFile("test.os", src)
+	return out.String(), err
+}
+
+// scriptTest is a script and either its output or the kind and message of
+// the error it stops with. vars are defined before the script runs.
+type scriptTest struct {
+	name    string
+	src     string
+	vars    map[string]any
+	out     string
+	errKind string
+	errMsg  string
+}
+
+func runScriptTests(t *testing.T, tests []scriptTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runWith(tt.src, Options{}, tt.vars)
+			if out != tt.out {
+				t.Errorf("output %q, want %q", out, tt.out)
+			}
+			if tt.errKind == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("error %v, want %s", err, tt.errKind)
+			}
+			if e.Kind != tt.errKind || !strings.Contains(e.Message, tt.errMsg) {
+				t.Errorf("error %s: %s, want %s containing %q", e.Kind, e.Message, tt.errKind, tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestComparisons(t *testing.T) {
+	runScriptTests(t, []scriptTest{
+		{name: "numbers", src: `print(1 < 2, 2 <= 2, 3 > 4, 2.5 >= 2, 1 == 1.0, 2 != 2.0)`,
+			out: "true\ntrue\nfalse\ntrue\ntrue\nfalse\n"},
+		{name: "large ints", src: `print(9007199254740993 > 9007199254740992)`, out: "true\n"},
+		{name: "strings", src: `print("abc" < "abd", "b" > "abc", "a" <= "a", "Z" < "a")`,
+			out: "true\ntrue\ntrue\ntrue\n"},
+		{name: "numeric string is not a number", src: `print(1 == "1", "1" != 1, "2" == "2.0")`,
+			out: "false\ntrue\nfalse\n"},
+		{name: "null and bools", src: `print(null == null, null == false, true == true, 0 == false)`,
+			out: "true\nfalse\ntrue\nfalse\n"},
+		{name: "arrays by identity", src: `let a = [1]
+let b = a
+print(a == b, a == [1])`, out: "true\nfalse\n"},
+		{name: "chained ordering", src: `print(1 < 2 < 3)`,
+			errKind: KindType, errMsg: "cannot compare bool < int"},
+		{name: "string and number", src: `print("10" > 9)`,
+			errKind: KindType, errMsg: "cannot compare string > int"},
+		{name: "null ordering", src: `print(null <= 1)`,
+			errKind: KindType, errMsg: "cannot compare null <= int"},
+		{name: "contains uses ==", src: `print(contains([1, 2], 2.0), contains(["1"], 1))`,
+			out: "true\nfalse\n"},
+	})
+}
+
+func TestUndefinedCalls(t *testing.T) {
+	runtime.InitBuiltins()
+	runScriptTests(t, []scriptTest{
+		{name: "undefined function", src: `undefinedFn(1)`,
+			errKind: KindReference, errMsg: "undefined variable undefinedFn"},
+		{name: "undefined object", src: `nothing.here(1)`,
+			errKind: KindReference, errMsg: "undefined variable nothing"},
+		{name: "undefined inside function", src: `fn f() { return g() }
+f()`, errKind: KindReference, errMsg: "undefined variable g"},
+		{name: "caught by kind", src: `try { missing() } catch (e) { print(e.kind) }`,
+			out: "ReferenceError\n"},
+		{name: "missing runtime member", src: `db.nosuch(1)`,
+			errKind: KindBuiltin, errMsg: "db has no member nosuch"},
+		{name: "not a function", src: `let n = 1
+n()`, errKind: KindBuiltin, errMsg: "int is not a function"},
+	})
+}
+
+func TestCaughtErrorFile(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.os")
+	libPath := filepath.Join(dir, "lib.os")
+	if err := os.WriteFile(libPath, []byte("export fn fail() {\n  return missing\n}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mainSrc := `import { fail } from "./lib"
+try { fail() } catch (e) { print(e.file == "` + libPath + `", e.line) }
+try { [][1] } catch (e) { print(e.file == "` + mainPath + `", e.line) }
+try { throw "x" } catch (e) { print(e) }`
+
+	for _, noBytecode := range []bool{false, true} {
+		var out strings.Builder
+		if err := New(Options{Output: &out, NoBytecode: noBytecode}).RunFile(mainPath, mainSrc); err != nil {
+			t.Fatal(err)
+		}
+		if want := "true\n2\ntrue\n3\nx\n"; out.String() != want {
+			t.Errorf("NoBytecode %v: output %q, want %q", noBytecode, out.String(), want)
+		}
+	}
+}
+
+func TestPrint(t *testing.T) {
+	runScriptTests(t, []scriptTest{
+		{name: "each argument on its own line", src: `print(1, "a", true)`, out: "1\na\ntrue\n"},
+		{name: "null", src: `print(null)`, out: "null\n"},
+		{name: "null among values", src: `let x = null
+print("x:", x, [null])`, out: "x:\nnull\n[null]\n"},
+		{name: "result of a void call", src: `fn f() {}
+print(f())`, out: "null\n"},
+	})
+}
+
+func TestNumbers(t *testing.T) {
+	runScriptTests(t, []scriptTest{
+		{name: "int arithmetic stays int", src: `print(7 + 2, 7 - 9, 6 * 7, 7 / 2, -7 / 2, 7 % 3)`,
+			out: "9\n-2\n42\n3\n-3\n1\n"},
+		{name: "float operand promotes", src: `print(7 / 2.0, 1 + 0.5, 2 * 1.5, 3.0 - 1)`,
+			out: "3.5\n1.5\n3.0\n2.0\n"},
+		{name: "floats keep .0", src: `print(1.0, 2.5 * 2, 1e3, -0.0, 1e21, 1e-7)`,
+			out: "1.0\n5.0\n1000.0\n-0.0\n1e+21\n1e-07\n"},
+		{name: "float formatting in strings", src: "print(\"x\" + 2.0, `${4.0 / 2}`)",
+			out: "x2.0\n2.0\n"},
+		{name: "max int", src: `print(9223372036854775807)`, out: "9223372036854775807\n"},
+		{name: "add overflow", src: `print(9223372036854775807 + 1)`,
+			errKind: KindArith, errMsg: "integer overflow"},
+		{name: "sub overflow", src: `print(-9223372036854775807 - 2)`,
+			errKind: KindArith, errMsg: "integer overflow"},
+		{name: "mul overflow", src: `print(4611686018427387904 * 2)`,
+			errKind: KindArith, errMsg: "integer overflow"},
+		{name: "int division by zero", src: `print(1 / 0)`,
+			errKind: KindArith, errMsg: "division by zero"},
+		{name: "modulo by zero", src: `print(1 % 0)`,
+			errKind: KindArith, errMsg: "modulo by zero"},
+		{name: "int()", src: `print(int(3.9), int(-3.9), int("42"), int(" 7 "), int("2.5"), int(true), int(5))`,
+			out: "3\n-3\n42\n7\n2\n1\n5\n"},
+		{name: "int() of bad string", src: `int("abc")`,
+			errKind: KindBuiltin, errMsg: `int: cannot parse "abc"`},
+		{name: "int() out of range", src: `int(1e19)`,
+			errKind: KindBuiltin, errMsg: "float 10000000000000000000.0 is out of int range"},
+		{name: "int() of array", src: `int([])`,
+			errKind: KindBuiltin, errMsg: "int: cannot convert array"},
+		{name: "float()", src: `print(float(3), float("2.5"), float(false), float(1.5))`,
+			out: "3.0\n2.5\n0.0\n1.5\n"},
+		{name: "float() of bad string", src: `float("x")`,
+			errKind: KindBuiltin, errMsg: `float: cannot parse "x"`},
+		{name: "str()", src: `print(str(3) + str(3.0) + str(null) + str(true) + str([1, "a"]))`,
+			out: "33.0nulltrue[1, \"a\"]\n"},
+		{name: "int and float are distinct types", src: `print(1 == 1.0, str(1) == str(1.0))`,
+			out: "true\nfalse\n"},
+	})
+}
+
+type owner struct{ Name string }
+
+func (o owner) Greet(greeting string) string { return greeting + ", " + o.Name }
+
+type account struct {
+	Owner   owner
+	Balance int
+	secret  string
+}
+
+func (a *account) Deposit(n int) int {
+	a.Balance += n
+	return a.Balance
+}
+
+func (a *account) Holder() *owner { return &a.Owner }
+
+func TestMemberPaths(t *testing.T) {
+	runtime.InitBuiltins()
+	acct := func() map[string]any {
+		return map[string]any{"acct": &account{Owner: owner{Name: "Ada"}, Balance: 10, secret: "x"}}
+	}
+	group := map[string]any{"group": map[string]any{
+		"inner": map[string]any{"twice": func(n int) int { return 2 * n }},
+	}}
+	runScriptTests(t, []scriptTest{
+		{name: "object path", src: `let a = {b: {c: fn(x) { return x + 1 }}}
+print(a.b.c(1))`, out: "2\n"},
+		{name: "array element path", src: `let a = {b: [{c: fn() { return "ok" }}]}
+let first = a.b[0]
+print(first.c())`, out: "ok\n"},
+		{name: "method on Go value", src: `print(acct.Deposit(5), acct.Balance)`, vars: acct(), out: "15\n15\n"},
+		{name: "method through field", src: `print(acct.Owner.Greet("Hi"))`, vars: acct(), out: "Hi, Ada\n"},
+		{name: "method on method result", src: `let h = acct.Holder()
+print(h.Greet("Hello"), h.Name)`, vars: acct(), out: "Hello, Ada\nAda\n"},
+		{name: "Go map path", src: `print(group.inner.twice(21))`, vars: group, out: "42\n"},
+		{name: "runtime symbol path", src: `let token = auth.generateToken("u")
+print(auth.validateToken(token))`, out: "u\n"},
+		{name: "missing object member mid-path", src: `let a = {b: {}}
+a.b.c.d()`, errKind: KindReference, errMsg: "a.b.c has no member d"},
+		{name: "missing Go field mid-path", src: `acct.Nope.Greet("Hi")`, vars: acct(),
+			errKind: KindReference, errMsg: "acct has no member Nope"},
+		{name: "unexported Go field", src: `print(acct.secret)`, vars: acct(),
+			errKind: KindReference, errMsg: "acct has no member secret"},
+		{name: "missing method", src: `acct.Owner.Wave()`, vars: acct(),
+			errKind: KindReference, errMsg: "acct.Owner has no member Wave"},
+		{name: "missing Go map entry mid-path", src: `group.outer.twice(1)`, vars: group,
+			errKind: KindReference, errMsg: "group has no member outer"},
+		{name: "missing runtime member mid-path", src: `db.tables.insert(1)`,
+			errKind: KindBuiltin, errMsg: "db has no member tables"},
+		{name: "member of a runtime function", src: `http.writeJSON.call(1)`,
+			errKind: KindBuiltin, errMsg: "http.writeJSON has no member call"},
+	})
+}
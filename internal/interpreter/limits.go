@@ -0,0 +1,25 @@
+package interpreter
+
+import (
+	"context"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+)
+
+// step counts one statement or loop iteration run by the thread and stops
+// the thread once its context is done or it has used up MaxSteps. Every
+// later step fails the same way, so a catch block cannot keep a cancelled
+// script running.
+func (th *thread) step(node ast.Node) error {
+	*th.steps++
+	if max := th.in.opts.MaxSteps; max > 0 && *th.steps > max {
+		return newError(KindLimit, node, "step budget of %d exceeded", max)
+	}
+	if err := th.ctx.Err(); err != nil {
+		if err == context.DeadlineExceeded {
+			return newError(KindTimeout, node, "execution timed out")
+		}
+		return newError(KindCancel, node, "execution cancelled")
+	}
+	return nil
+}
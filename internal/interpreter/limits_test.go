@@ -0,0 +1,118 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+)
+
+// callbackInterpreter has apply(f), a registered builtin calling f through
+// Function.Call, and call(f), a Go function taking f as a func() error.
+func callbackInterpreter(opts Options) *Interpreter {
+	opts.Output = io.Discard
+	opts.Diagnostics = &diag.Printer{Out: io.Discard}
+	in := New(opts)
+	in.Register("apply", func(args []any) (any, error) {
+		return args[0].(*Function).Call()
+	})
+	in.SetVariable("call", func(f func() error) error { return f() })
+	return in
+}
+
+// A script stuck in a callback must stop with the run that called the Go
+// function making the callback.
+func TestCallbackStopsWithCaller(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		{"registered builtin", `apply(fn() { while true {} })`},
+		{"Go function", `call(fn() { while true {} })`},
+		{"nested", `apply(fn() { call(fn() { while true {} }) })`},
+		{"inside a catch", `apply(fn() {
+  try { while true {} } catch (e) { while true {} }
+})`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			var err error
+			within(t, func() {
+				err = callbackInterpreter(Options{}).RunFileContext(ctx, "test.os", tt.src)
+			})
+			var e *Error
+			if !errors.As(err, &e) || e.Kind != KindTimeout {
+				t.Errorf("error %v, want %s", err, KindTimeout)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	var err error
+	within(t, func() {
+		err = callbackInterpreter(Options{}).RunFileContext(ctx, "test.os", `apply(fn() { while true {} })`)
+	})
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != KindCancel {
+		t.Errorf("cancelled run: error %v, want %s", err, KindCancel)
+	}
+}
+
+// Callbacks count towards the step budget and call depth of their caller
+// rather than starting afresh.
+func TestCallbackSharesLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		opts Options
+		kind string
+		msg  string
+	}{
+		{name: "steps", src: `for k in 0..10 {
+  apply(fn() { for j in 0..5 { } })
+}`, opts: Options{MaxSteps: 40}, kind: KindLimit, msg: "step budget of 40 exceeded"},
+		{name: "call depth", src: `fn down(n) { call(fn() { down(n + 1) }) }
+down(0)`, opts: Options{MaxCallDepth: 20}, kind: KindLimit, msg: "maximum call depth of 20 exceeded"},
+		{name: "Options.Timeout", src: `call(fn() { while true {} })`,
+			opts: Options{Timeout: 50 * time.Millisecond}, kind: KindTimeout, msg: "execution timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			within(t, func() {
+				err = callbackInterpreter(tt.opts).RunFile("test.os", tt.src)
+			})
+			var e *Error
+			if !errors.As(err, &e) || e.Kind != tt.kind || e.Message != tt.msg {
+				t.Errorf("error %v, want %s: %s", err, tt.kind, tt.msg)
+			}
+		})
+	}
+}
+
+// A callback made after the call that passed it to Go has returned, such
+// as a route handler, does not inherit that finished call's limits.
+func TestStoredCallbackRunsAfresh(t *testing.T) {
+	in := callbackInterpreter(Options{MaxSteps: 20})
+	var stored func() error
+	in.SetVariable("keep", func(f func() error) { stored = f })
+	if err := in.RunFile("test.os", `let n = 0
+keep(fn() { n += 1 })
+for i in 0..5 { n += 0 }`); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := stored(); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if v, _ := in.GetVariable("n"); v != int64(10) {
+		t.Errorf("n = %v, want 10", v)
+	}
+}
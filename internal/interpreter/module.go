@@ -0,0 +1,189 @@
+package interpreter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+	"github.com/intellidevelopers/osun-lang/internal/parser"
+)
+
+// module is one .os file with its own top-level scope. Only names declared
+// with export can be imported from it.
+type module struct {
+	in      *Interpreter
+	name    string // path as shown in diagnostics
+	path    string // absolute path and cache key; empty for code without a file
+	src     *diag.Source
+	env     *Environment
+	exports map[string]bool
+	loaded  bool  // false while the top level is still running
+	unit    *unit // parsed code, shared with later runs of the same file
+}
+
+func (in *Interpreter) newModule(name, path, code string) *module {
+	m := &module{
+		in:      in,
+		name:    name,
+		path:    path,
+		src:     diag.NewSource(name, code),
+		env:     NewEnvironment(in.globals),
+		exports: map[string]bool{},
+	}
+	in.sources[name] = m.src
+	return m
+}
+
+// runModule parses and runs a module's top level.
+func (th *thread) runModule(m *module, code string) error {
+	u, err := th.in.load(m.path, m.name, code)
+	if err != nil {
+		return err
+	}
+	m.unit = u
+
+	prev := th.current
+	th.current = m
+	th.loading = append(th.loading, m)
+	defer func() {
+		th.current = prev
+		th.loading = th.loading[:len(th.loading)-1]
+	}()
+
+	if err := th.executeBlock(u.prog.Statements, m.env); err != nil {
+		return err
+	}
+	m.loaded = true
+	return nil
+}
+
+// syntaxError is the list of syntax errors found in one file.
+type syntaxError struct {
+	file string
+	list parser.ErrorList
+}
+
+func (e *syntaxError) Error() string { return e.file + ": " + e.list.Error() }
+func (e *syntaxError) Unwrap() error { return e.list }
+
+// -------------------- Import / Export ------------------------
+
+func (th *thread) handleImport(stmt *ast.ImportStatement, env *Environment) error {
+	m, err := th.importModule(stmt)
+	if err != nil {
+		return err
+	}
+	for _, n := range stmt.Names {
+		if !m.exports[n.Name.Name] {
+			return newError(KindImport, n.Name, "%s does not export %s", m.name, n.Name.Name)
+		}
+		val, _ := m.env.Get(n.Name.Name)
+		local := n.Name
+		if n.Alias != nil {
+			local = n.Alias
+		}
+		// Imported bindings cannot be reassigned by the importer.
+		if err := env.Declare(local.Name, val, true); err != nil {
+			return newError(KindReference, local, "%v", err)
+		}
+	}
+	return nil
+}
+
+// importModule returns the module named by an import, loading and running
+// it the first time it is imported.
+func (th *thread) importModule(stmt *ast.ImportStatement) (*module, error) {
+	path := th.resolveImport(stmt.Path.Value)
+	if m, ok := th.in.modules[path]; ok {
+		if !m.loaded {
+			return nil, newError(KindImport, stmt.Path, "import cycle: %s", th.importCycle(m))
+		}
+		return m, nil
+	}
+
+	code, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, newError(KindImport, stmt.Path, "cannot find module %q", stmt.Path.Value)
+		}
+		return nil, newError(KindImport, stmt.Path, "cannot read module %q: %v", stmt.Path.Value, err)
+	}
+
+	m := th.in.newModule(displayName(path), path, string(code))
+	th.in.modules[path] = m
+	th.site = stmt.Pos()
+	th.pushCall("<module " + m.name + ">")
+	defer th.popCall()
+	if err := th.runModule(m, string(code)); err != nil {
+		delete(th.in.modules, path)
+		return nil, err
+	}
+	return m, nil
+}
+
+// resolveImport turns an import path into an absolute file path. Relative
+// paths are resolved against the importing file's directory, and a missing
+// extension defaults to .os.
+func (th *thread) resolveImport(spec string) string {
+	path := filepath.FromSlash(spec)
+	if filepath.Ext(path) == "" {
+		path += ".os"
+	}
+	if !filepath.IsAbs(path) {
+		dir := "."
+		if th.current != nil && th.current.path != "" {
+			dir = filepath.Dir(th.current.path)
+		}
+		path = filepath.Join(dir, path)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// displayName shortens path relative to the working directory when it is
+// inside it.
+func displayName(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
+
+// importCycle describes the chain of imports that leads back to m.
+func (th *thread) importCycle(m *module) string {
+	var names []string
+	for i, l := range th.loading {
+		if l == m {
+			for _, c := range th.loading[i:] {
+				names = append(names, c.name)
+			}
+			break
+		}
+	}
+	return strings.Join(append(names, m.name), " -> ")
+}
+
+func (th *thread) handleExport(stmt *ast.ExportStatement, env *Environment) error {
+	if err := th.execStatement(stmt.Decl, env); err != nil {
+		return err
+	}
+	if th.current != nil {
+		switch d := stmt.Decl.(type) {
+		case *ast.LetStatement:
+			th.current.exports[d.Name.Name] = true
+		case *ast.FunctionStatement:
+			th.current.exports[d.Name.Name] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package interpreter
+
+import "testing"
+
+func TestModules(t *testing.T) {
+	const dir = "testdata/modules/"
+	runScriptTests(t, []scriptTest{
+		{name: "named imports", src: `import { greet, version, answer } from "./testdata/modules/lib"
+print(greet("Ada"), version, answer)`, out: "counter loaded\nhi Ada\n2\n42\n"},
+		{name: "alias", src: `import { greet as hello, version as v } from "./testdata/modules/lib.os"
+print(hello("Bo"), v)`, out: "counter loaded\nhi Bo\n2\n"},
+		{name: "alias hides the exported name", src: `import { greet as hello } from "./testdata/modules/lib"
+greet("Bo")`, out: "counter loaded\n", errKind: KindReference, errMsg: "undefined variable greet"},
+		{name: "module runs once per run", src: `import "./testdata/modules/counter"
+import { count } from "./testdata/modules/counter"
+import { version } from "./testdata/modules/lib"
+print(count, version)`, out: "counter loaded\n0\n2\n"},
+		{name: "imports cannot be reassigned", src: `import { version } from "./testdata/modules/lib"
+version = 3`, out: "counter loaded\n", errKind: KindReference, errMsg: "cannot assign to constant version"},
+		{name: "does not export", src: `import { hidden } from "./testdata/modules/lib"`,
+			out: "counter loaded\n", errKind: KindImport, errMsg: dir + "lib.os does not export hidden"},
+		{name: "unknown name", src: `import { greet, nope } from "./testdata/modules/lib"`,
+			out: "counter loaded\n", errKind: KindImport, errMsg: dir + "lib.os does not export nope"},
+		{name: "import cycle", src: `import { a } from "./testdata/modules/cycle/a"`,
+			errKind: KindImport, errMsg: "import cycle: " + dir + "cycle/a.os -> " + dir + "cycle/b.os -> " + dir + "cycle/a.os"},
+		{name: "import cycle below the main file", src: `import { c } from "./testdata/modules/indirect"`,
+			errKind: KindImport, errMsg: "import cycle: " + dir + "cycle/b.os -> " + dir + "cycle/a.os -> " + dir + "cycle/b.os"},
+		{name: "missing module", src: `import { x } from "./testdata/modules/missing"`,
+			errKind: KindImport, errMsg: `cannot find module "./testdata/modules/missing"`},
+	})
+}
@@ -0,0 +1,111 @@
+package interpreter
+
+import (
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// Osun has two number types: int (int64) and float (float64). Operations on
+// two ints stay ints, with division truncating toward zero and overflow
+// reported as an error; an operation with a float operand is done in float.
+
+var errOverflow = errors.New("integer overflow")
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+// intArith applies + - * / % to two ints, failing on overflow and on
+// division by zero.
+func intArith(op token.Kind, a, b int64) (int64, error) {
+	switch op {
+	case token.PLUS:
+		if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+			return 0, errOverflow
+		}
+		return a + b, nil
+	case token.MINUS:
+		if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+			return 0, errOverflow
+		}
+		return a - b, nil
+	case token.STAR:
+		if a == 0 || b == 0 {
+			return 0, nil
+		}
+		c := a * b
+		if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+			return 0, errOverflow
+		}
+		return c, nil
+	case token.SLASH:
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		if a == math.MinInt64 && b == -1 {
+			return 0, errOverflow
+		}
+		return a / b, nil
+	case token.PERCENT:
+		if b == 0 {
+			return 0, errors.New("modulo by zero")
+		}
+		if b == -1 {
+			return 0, nil
+		}
+		return a % b, nil
+	}
+	return 0, errors.New("unsupported operator " + op.String())
+}
+
+// floatArith applies + - * / % to two floats.
+func floatArith(op token.Kind, a, b float64) (float64, error) {
+	switch op {
+	case token.PLUS:
+		return a + b, nil
+	case token.MINUS:
+		return a - b, nil
+	case token.STAR:
+		return a * b, nil
+	case token.SLASH:
+		if b == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return a / b, nil
+	case token.PERCENT:
+		if b == 0 {
+			return 0, errors.New("modulo by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+	return 0, errors.New("unsupported operator " + op.String())
+}
+
+// floatToInt converts f to an int, truncating toward zero.
+func floatToInt(f float64) (int64, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.New("float " + formatFloat(f) + " is out of int range")
+	}
+	return int64(f), nil
+}
+
+// formatFloat prints f without an exponent unless it is very large or
+// very small, so 1000000.0 prints as 1000000.0 rather than 1e+06. A whole
+// float keeps its ".0" so that it never prints like an int.
+func formatFloat(f float64) string {
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if f == math.Trunc(f) && !math.IsInf(f, 0) {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,137 @@
+package interpreter
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/intellidevelopers/osun-lang/internal/diag"
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// thread runs script code for one caller: RunFile, Eval, Call, or a call
+// into the script from Go such as an HTTP handler. It holds the call stack
+// and the running module; everything shared lives on the Interpreter.
+type thread struct {
+	in *Interpreter
+
+	// stack holds the calls in progress, outermost first.
+	stack []callFrame
+
+	// site is the position of the call expression being invoked, recorded
+	// just before control leaves evalCall so the callee's frame can point
+	// back at it, even when a Go builtin calls the script function.
+	site token.Pos
+
+	// current is the module whose code is running. Function calls switch
+	// it to the module the function was defined in.
+	current *module
+
+	// loading lists the modules whose top level is running, outermost
+	// first, to describe import cycles.
+	loading []*module
+
+	// ctx cancels the thread; Go functions taking a context receive it.
+	// steps counts statements and loop iterations against MaxSteps; a
+	// callback thread shares the count of the thread it continues.
+	ctx   context.Context
+	steps *int
+
+	// waiting is set while the thread is blocked in a Go function it
+	// called. Callbacks that function makes continue the thread.
+	waiting bool
+
+	// values is the bytecode VM's stack of local slots and operands.
+	values []any
+}
+
+// newThread starts a thread that stops once ctx is done or, when timeout
+// is positive, once timeout has passed. The caller must call cancel when
+// the thread finishes.
+func (in *Interpreter) newThread(ctx context.Context, timeout time.Duration) (th *thread, cancel context.CancelFunc) {
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	return &thread{in: in, ctx: ctx, steps: new(int)}, cancel
+}
+
+// callbackThread starts the thread for a call into the script from Go.
+// While caller, a thread of in, is waiting in the Go function making the
+// call, the new thread continues caller: it stops with caller's context,
+// shares its step budget and keeps its call stack, so a callback can
+// neither outlive a cancelled run nor reset its limits. Otherwise it is
+// newThread(ctx, timeout).
+func (in *Interpreter) callbackThread(caller *thread, ctx context.Context, timeout time.Duration) (*thread, context.CancelFunc) {
+	if caller == nil || caller.in != in || !caller.waiting {
+		return in.newThread(ctx, timeout)
+	}
+	ctx, cancel := context.WithCancel(caller.ctx)
+	return &thread{in: in, ctx: ctx, steps: caller.steps, stack: slices.Clone(caller.stack)}, cancel
+}
+
+// callFrame is one script function call in progress. site is where the
+// caller made the call; origin labels an entry from Go code, such as an
+// HTTP request, below which the script has no callers.
+type callFrame struct {
+	name   string
+	site   token.Pos
+	file   string // file containing site
+	origin string
+}
+
+func (th *thread) pushCall(name string) {
+	th.stack = append(th.stack, callFrame{name: name, site: th.site, file: th.currentFile()})
+}
+
+// pushOrigin marks a call into the script from Go that serves an HTTP
+// request, labelled with the request's method and path.
+func (th *thread) pushOrigin(r *http.Request) {
+	th.stack = append(th.stack, callFrame{origin: "HTTP " + r.Method + " " + r.URL.Path})
+}
+
+// requestOf returns the HTTP request among the arguments of a call from
+// Go, or nil when the call is not serving one.
+func requestOf(args []reflect.Value) *http.Request {
+	for _, a := range args {
+		if r, ok := a.Interface().(*http.Request); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+func (th *thread) popCall() {
+	th.stack = th.stack[:len(th.stack)-1]
+}
+
+// currentFile names the file whose code is running, for error locations.
+func (th *thread) currentFile() string {
+	if th.current == nil {
+		return ""
+	}
+	return th.current.name
+}
+
+// stackTrace describes the current call stack for an error raised at pos,
+// innermost frame first.
+func (th *thread) stackTrace(pos token.Pos) []diag.Frame {
+	var trace []diag.Frame
+	file := th.currentFile()
+	for i := len(th.stack) - 1; i >= 0; i-- {
+		c := th.stack[i]
+		if c.origin != "" {
+			return append(trace, diag.Frame{Function: c.origin})
+		}
+		trace = append(trace, frameAt(c.name, file, pos))
+		pos, file = c.site, c.file
+	}
+	return append(trace, frameAt("<main>", file, pos))
+}
+
+func frameAt(name, file string, pos token.Pos) diag.Frame {
+	return diag.Frame{Function: name, File: file, Line: pos.Line, Column: pos.Col}
+}
@@ -0,0 +1,252 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Array is an Osun list. It is always handled by pointer so that push/pop
+// and index assignment are visible through every reference.
+type Array struct {
+	Elements []any
+}
+
+// NewArray wraps elems in an Array.
+func NewArray(elems ...any) *Array {
+	return &Array{Elements: elems}
+}
+
+func (a *Array) String() string {
+	parts := make([]string, len(a.Elements))
+	for i, v := range a.Elements {
+		parts[i] = formatElement(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// MarshalJSON encodes the array as a plain JSON list. The elements are
+// marshalled as they are, so nested objects keep their key order.
+func (a *Array) MarshalJSON() ([]byte, error) {
+	if a.Elements == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(a.Elements)
+}
+
+// toInt converts an int, or a float with no fractional part, to an int.
+func toInt(v any) (int, error) {
+	switch n := v.(type) {
+	case int64:
+		return int(n), nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, fmt.Errorf("expected a whole number, got %s", formatFloat(n))
+		}
+		i, err := floatToInt(n)
+		return int(i), err
+	}
+	return 0, fmt.Errorf("expected an int, got %s", typeName(v))
+}
+
+// toIndex validates v as an index into a sequence of the given length.
+func toIndex(v any, length int) (int, error) {
+	i, err := toInt(v)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 {
+		return 0, fmt.Errorf("negative index %d", i)
+	}
+	if i >= length {
+		return 0, fmt.Errorf("index %d out of range for length %d", i, length)
+	}
+	return i, nil
+}
+
+// formatElement formats a value nested inside a collection, quoting strings
+// so that ["1"] and [1] print differently.
+func formatElement(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return formatValue(v)
+}
+
+// formatKey leaves identifier-like keys bare and quotes everything else.
+func formatKey(k string) string {
+	for i, r := range k {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return strconv.Quote(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+// fromGoValue converts a result returned by a Go builtin into an Osun value:
+// integers become int64 and floats float64, slices become arrays and string-keyed maps become
+// objects (with keys sorted, since Go maps have no order). Slice and map types
+// with methods, such as http.Header, are passed through so their methods stay
+// callable, as is anything else, such as a *runtime.OsunServer.
+func fromGoValue(v any) any {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map {
+		if rv.Type().NumMethod() > 0 {
+			return v
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(rv.Bytes())
+		}
+		arr := NewArray()
+		for i := 0; i < rv.Len(); i++ {
+			arr.Elements = append(arr.Elements, fromGoValue(rv.Index(i).Interface()))
+		}
+		return arr
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		keys := make([]string, 0, rv.Len())
+		for _, k := range rv.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		obj := NewObject()
+		for _, k := range keys {
+			obj.Set(k, fromGoValue(rv.MapIndex(reflect.ValueOf(k).Convert(rv.Type().Key())).Interface()))
+		}
+		return obj
+	}
+	return v
+}
+
+// snapshot returns v with its arrays and objects deeply copied. Go code
+// that runs with the interpreter unlocked is given snapshots, so scripts
+// changing the originals meanwhile cannot race with it. Shared and cyclic
+// references are copied once and stay shared in the copy. Functions in v
+// are bound to caller, the thread handing them to Go, when it is not nil.
+func snapshot(v any, caller *thread) any {
+	return copyValue(v, caller, map[any]any{})
+}
+
+func copyValue(v any, caller *thread, copies map[any]any) any {
+	switch t := v.(type) {
+	case *Function:
+		if caller == nil || t.caller == caller {
+			return t
+		}
+		bound := *t
+		bound.caller = caller
+		return &bound
+	case *Array:
+		if c, ok := copies[t]; ok {
+			return c
+		}
+		c := &Array{Elements: make([]any, len(t.Elements))}
+		copies[t] = c
+		for i, e := range t.Elements {
+			c.Elements[i] = copyValue(e, caller, copies)
+		}
+		return c
+	case *Object:
+		if c, ok := copies[t]; ok {
+			return c
+		}
+		c := &Object{keys: append([]string(nil), t.keys...), values: make(map[string]any, len(t.values))}
+		copies[t] = c
+		for k, e := range t.values {
+			c.values[k] = copyValue(e, caller, copies)
+		}
+		return c
+	}
+	return v
+}
+
+// Object is an Osun map with string keys. It remembers insertion order so
+// that printing and JSON encoding list keys the way the script wrote them.
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewObject creates an empty object.
+func NewObject() *Object {
+	return &Object{values: map[string]any{}}
+}
+
+// Get returns the value stored under key.
+func (o *Object) Get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Set stores value under key, appending key to the order if it is new.
+func (o *Object) Set(key string, value any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Keys returns the keys in insertion order.
+func (o *Object) Keys() []string {
+	return o.keys
+}
+
+// Len returns the number of keys.
+func (o *Object) Len() int {
+	return len(o.keys)
+}
+
+func (o *Object) String() string {
+	parts := make([]string, len(o.keys))
+	for i, k := range o.keys {
+		parts[i] = formatKey(k) + ": " + formatElement(o.values[k])
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// MarshalJSON encodes the object with its keys in insertion order.
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(o.values[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,318 @@
+package interpreter
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+)
+
+// errVMReturn unwinds nested runs of a frame to the call when the function
+// returns; the result is in frame.result.
+var errVMReturn = errors.New("return")
+
+// frame is one call of a compiled function. Its local slots and operands
+// live on the thread's value stack, locals first from base.
+type frame struct {
+	code     *chunk
+	fn       *Function
+	base     int
+	counters []int
+	result   any
+}
+
+func (th *thread) push(v any) { th.values = append(th.values, v) }
+
+func (th *thread) pop() any {
+	v := th.values[len(th.values)-1]
+	th.values = th.values[:len(th.values)-1]
+	return v
+}
+
+func (th *thread) top() any { return th.values[len(th.values)-1] }
+
+// popN removes and returns the top n values, oldest first. The result
+// aliases the stack, so it must be copied before the stack grows again.
+func (th *thread) popN(n int) []any {
+	vals := th.values[len(th.values)-n:]
+	th.values = th.values[:len(th.values)-n]
+	return vals
+}
+
+// bytecode returns the compiled form of f, compiling it on first use, or
+// nil when f runs on the tree-walking evaluator. The bytecode is cached in
+// the unit f was parsed from.
+func (in *Interpreter) bytecode(f *Function) *chunk {
+	if in.opts.NoBytecode {
+		return nil
+	}
+	chunks := f.module.unit.chunks
+	code, ok := chunks[f.decl]
+	if !ok {
+		code, _ = compileFunction(f.decl)
+		chunks[f.decl] = code
+	}
+	return code
+}
+
+// runChunk runs a compiled call of f with its arguments.
+func (th *thread) runChunk(code *chunk, f *Function, args []any) (any, error) {
+	fr := &frame{code: code, fn: f, base: len(th.values)}
+	th.values = append(th.values, args...)
+	for i := len(args); i < code.locals; i++ {
+		th.values = append(th.values, nil)
+	}
+	defer func() {
+		clear(th.values[fr.base:])
+		th.values = th.values[:fr.base]
+	}()
+	if code.counters > 0 {
+		fr.counters = make([]int, code.counters)
+	}
+	_, err := th.run(fr, 0, len(code.code))
+	if err == errVMReturn {
+		return fr.result, nil
+	}
+	return nil, err
+}
+
+// run executes the code in [start, end) and returns where execution leaves
+// that range: end when it runs off the end, or the target of a jump out of
+// it such as a break. A for-in body, or a block of a try statement, is run
+// as a nested range so its statement can act on how the range was left.
+func (th *thread) run(fr *frame, start, end int) (int, error) {
+	code := fr.code
+	for pc := start; ; {
+		if pc < start || pc >= end {
+			return pc, nil
+		}
+		in := code.code[pc]
+		pc++
+		var err error
+		switch in.op {
+		case opConst:
+			th.push(code.consts[in.a])
+		case opPop:
+			th.pop()
+		case opGetLocal:
+			th.push(th.values[fr.base+in.a])
+		case opSetLocal:
+			th.values[fr.base+in.a] = th.pop()
+		case opGetName:
+			var v any
+			v, err = th.evalIdentifier(code.nodes[in.a].(*ast.Identifier), fr.fn.closure)
+			th.push(v)
+
+		case opUpdateLocal:
+			var v any
+			if v, err = applyUpdate(code.nodes[in.b].(ast.Statement), th.values[fr.base+in.a], th.pop()); err == nil {
+				th.values[fr.base+in.a] = v
+			}
+		case opAssignName:
+			err = th.assignName(fr, in)
+		case opCheckField:
+			if _, ok := th.top().(*Object); !ok {
+				t := code.nodes[in.a].(*ast.MemberExpression)
+				err = newError(KindType, t, "cannot set field %q on %s", t.Property.Name, typeName(th.top()))
+			}
+		case opSetField:
+			t := code.nodes[in.a].(*ast.MemberExpression)
+			val := th.pop()
+			obj := th.pop().(*Object)
+			if in.b >= 0 {
+				cur, _ := obj.Get(t.Property.Name)
+				val, err = applyUpdate(code.nodes[in.b].(ast.Statement), cur, val)
+			}
+			if err == nil {
+				obj.Set(t.Property.Name, val)
+			}
+		case opSetIndex:
+			t := code.nodes[in.a].(*ast.IndexExpression)
+			vals := th.popN(3)
+			obj, idx, val := vals[0], vals[1], vals[2]
+			if in.b >= 0 {
+				var cur any
+				if cur, err = indexValue(t, obj, idx); err == nil {
+					val, err = applyUpdate(code.nodes[in.b].(ast.Statement), cur, val)
+				}
+			}
+			if err == nil {
+				err = setIndex(t, obj, idx, val)
+			}
+
+		case opJump:
+			pc = in.a
+		case opJumpIfFalse:
+			if !isTruthy(th.pop()) {
+				pc = in.a
+			}
+		case opJumpIfFalseKeep:
+			if th.top() == false {
+				pc = in.a
+			} else {
+				th.pop()
+			}
+		case opJumpIfTrueKeep:
+			if th.top() == true {
+				pc = in.a
+			} else {
+				th.pop()
+			}
+		case opTruthy:
+			th.values[len(th.values)-1] = isTruthy(th.top())
+
+		case opUnary:
+			var v any
+			v, err = applyUnary(code.nodes[in.a].(*ast.UnaryExpression), th.pop())
+			th.push(v)
+		case opBinary:
+			e := code.nodes[in.a].(*ast.BinaryExpression)
+			vals := th.popN(2)
+			var v any
+			v, err = applyBinary(e, e.Op, vals[0], vals[1])
+			th.push(v)
+		case opIndex:
+			vals := th.popN(2)
+			var v any
+			v, err = indexValue(code.nodes[in.a].(*ast.IndexExpression), vals[0], vals[1])
+			th.push(v)
+		case opMember:
+			var v any
+			v, err = memberValue(code.nodes[in.a].(*ast.MemberExpression), th.pop())
+			th.push(v)
+		case opArray:
+			th.push(NewArray(append([]any(nil), th.popN(in.a)...)...))
+		case opObject:
+			e := code.nodes[in.a].(*ast.ObjectLiteral)
+			obj := NewObject()
+			for i, v := range th.popN(len(e.Keys)) {
+				obj.Set(e.Keys[i], v)
+			}
+			th.push(obj)
+		case opConcat:
+			var sb strings.Builder
+			for _, v := range th.popN(in.a) {
+				sb.WriteString(formatValue(v))
+			}
+			th.push(sb.String())
+
+		case opCall:
+			callee := th.pop()
+			args := append([]any(nil), th.popN(in.b)...)
+			var v any
+			v, err = th.callAt(code.nodes[in.a].(*ast.CallExpression), callee, args)
+			th.push(v)
+		case opCallPath:
+			args := append([]any(nil), th.popN(in.b)...)
+			var v any
+			site := code.consts[in.a].(callSite)
+			v, err = th.invoke(site.call, site.path, args, fr.fn.closure)
+			th.push(v)
+		case opReturn:
+			fr.result = th.pop()
+			return pc, errVMReturn
+		case opThrow:
+			err = th.raise(code.nodes[in.a].(*ast.ThrowStatement), th.pop())
+
+		case opStep:
+			err = th.step(code.nodes[in.a])
+		case opResetLoop:
+			fr.counters[in.a] = 0
+		case opLoopGuard:
+			fr.counters[in.a]++
+			err = th.checkLoopGuard(code.nodes[in.b].(ast.Statement), fr.counters[in.a])
+		case opForIn:
+			pc, err = th.runForIn(fr, &code.loops[in.a])
+		case opTry:
+			pc, err = th.runTry(fr, &code.tries[in.a])
+		case opNop:
+		}
+		if err != nil {
+			return pc, th.annotate(err)
+		}
+	}
+}
+
+// assignName runs opAssignName, assigning to a variable of the closure.
+func (th *thread) assignName(fr *frame, in instr) error {
+	t := fr.code.nodes[in.a].(*ast.Identifier)
+	val := th.pop()
+	if in.b >= 0 {
+		cur, err := th.evalIdentifier(t, fr.fn.closure)
+		if err != nil {
+			return err
+		}
+		if val, err = applyUpdate(fr.code.nodes[in.b].(ast.Statement), cur, val); err != nil {
+			return err
+		}
+	}
+	if err := fr.fn.closure.Set(t.Name, val); err != nil {
+		return newError(KindReference, t, "%v", err)
+	}
+	return nil
+}
+
+// runForIn runs a for-in loop whose iterable is on the stack, binding the
+// loop variables and running the body once per element.
+func (th *thread) runForIn(fr *frame, loop *forInfo) (int, error) {
+	next := loop.end + 1
+	iterations := 0
+	yield := func(key, value any) (bool, error) {
+		iterations++
+		if err := th.checkLoopGuard(loop.stmt, iterations); err != nil {
+			return false, err
+		}
+		if loop.key >= 0 {
+			th.values[fr.base+loop.key] = key
+		}
+		th.values[fr.base+loop.value] = value
+		pc, err := th.run(fr, loop.body, loop.end)
+		if err != nil || pc != loop.end {
+			next = pc
+			return false, err
+		}
+		return true, nil
+	}
+
+	var err error
+	if r, ok := loop.stmt.Iterable.(*ast.RangeExpression); ok {
+		bounds := th.popN(2)
+		err = iterateRange(r, bounds[0], bounds[1], yield)
+	} else {
+		err = iterateValue(loop.stmt, th.pop(), yield)
+	}
+	return next, err
+}
+
+// runTry runs a try statement with the semantics of handleTry: a catchable
+// error from the body runs the catch block, finally always runs, and a
+// finally block that does not complete normally overrides the others.
+func (th *thread) runTry(fr *frame, t *tryInfo) (int, error) {
+	height := len(th.values)
+	pc, err := th.run(fr, t.body, t.bodyEnd)
+	if err != nil {
+		// Drop operands of the expression that failed.
+		th.values = th.values[:height]
+	}
+	if err != nil && t.stmt.Catch != nil && catchable(err) {
+		if t.param >= 0 {
+			th.values[fr.base+t.param] = caughtValue(err)
+		}
+		pc, err = th.run(fr, t.catch, t.catchEnd)
+	} else if err == nil && pc == t.bodyEnd {
+		pc = t.catchEnd
+	}
+	if t.stmt.Finally != nil {
+		fpc, ferr := th.run(fr, t.final, t.finalEnd)
+		if ferr != nil {
+			th.values = th.values[:height]
+		}
+		if ferr != nil || fpc != t.finalEnd {
+			return fpc, ferr
+		}
+	}
+	if err == nil && pc == t.catchEnd {
+		pc = t.after
+	}
+	return pc, err
+}
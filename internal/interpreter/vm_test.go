@@ -0,0 +1,250 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type parityTest struct {
+	name string
+	src  string
+	opts Options
+}
+
+// parityTests exercise the statements and expressions the compiler
+// handles, along with the ones it leaves to the tree-walker.
+var parityTests = []parityTest{
+	{name: "arithmetic", src: `fn f(a, b) { return [a + b, a - b, a * b, a / b, a % b, -a, a / 2.0] }
+print(f(7, 3), f(1.5, 2))`},
+	{name: "strings", src: `fn f(s) {
+  let t = s + "!"
+  t += 1
+  return [t, s[0], len(s), "a" < "b", ` + "`${s}-${len(s)}`" + `]
+}
+print(f("osun"))`},
+	{name: "logic", src: `fn f(a, b) { return [a && b, a || b, !a, a == b, a != b, null == null] }
+print(f(true, false), f(1, 0), f("", "x"))`},
+	{name: "if else", src: `fn sign(n) {
+  if n < 0 { return -1 } else if n == 0 { return 0 }
+  return 1
+}
+print(sign(-5), sign(0), sign(5))`},
+	{name: "while break continue", src: `fn f() {
+  let i = 0
+  let out = []
+  while true {
+    i++
+    if i % 2 == 0 { continue }
+    if i > 9 { break }
+    push(out, i)
+  }
+  return out
+}
+print(f())`},
+	{name: "for-in", src: `fn f() {
+  let out = []
+  for i in 0..3 { push(out, i) }
+  for i, v in ["a", "b"] { push(out, v + i) }
+  for k, v in { x: 1, y: 2 } { push(out, k + v) }
+  for c in "hé" { push(out, c) }
+  for i in 0..10 {
+    if i == 2 { continue }
+    if i == 4 { break }
+    push(out, i * 10)
+  }
+  return out
+}
+print(f())`},
+	{name: "nested loops", src: `fn f() {
+  let n = 0
+  for i in 0..5 {
+    for j in 0..5 {
+      if j > i { break }
+      n += j
+    }
+  }
+  return n
+}
+print(f())`},
+	{name: "assignment targets", src: `fn f() {
+  let o = { a: 1, list: [1, 2] }
+  o.a += 4
+  o.b = "new"
+  o.list[1] *= 10
+  o.list[0]--
+  return o
+}
+print(f())`},
+	{name: "globals", src: `let count = 0
+fn bump(n) {
+  count += n
+  count++
+  return count
+}
+bump(2)
+print(bump(3), count)`},
+	{name: "closures", src: `fn counter() {
+  let n = 0
+  return fn() {
+    n++
+    return n
+  }
+}
+let c = counter()
+c()
+print(c(), c())`},
+	{name: "recursion", src: `fn fact(n) {
+  if n <= 1 { return 1 }
+  return n * fact(n - 1)
+}
+print(fact(20))`},
+	{name: "try catch finally", src: `fn f(x) {
+  let log = []
+  try {
+    push(log, "try")
+    if x { throw "boom" }
+    push(log, "after")
+  } catch (e) {
+    push(log, "caught " + e)
+  } finally {
+    push(log, "finally")
+  }
+  return log
+}
+print(f(false), f(true))`},
+	{name: "try in loop", src: `fn f() {
+  let out = []
+  for i in 0..4 {
+    try {
+      if i == 1 { continue }
+      if i == 3 { break }
+      push(out, 10 / (i - 2))
+    } catch (e) {
+      push(out, e.kind)
+    } finally {
+      push(out, "f" + i)
+    }
+  }
+  return out
+}
+print(f())`},
+	{name: "return from finally", src: `fn f() {
+  try { return 1 } finally { return 2 }
+}
+print(f())`},
+	{name: "caught runtime error", src: `fn f() {
+  try { return [1][5] } catch (e) { return [e.kind, e.message, e.line] }
+}
+print(f())`},
+	{name: "const", src: `fn f() {
+  const k = 3
+  return k * 2
+}
+print(f())`},
+	{name: "const reassign", src: `fn f() {
+  const k = 3
+  k = 4
+}
+f()`},
+	{name: "type error", src: `fn f(a) { return a - "x" }
+f(1)`},
+	{name: "uncaught throw", src: `fn inner() { throw { code: 7 } }
+fn outer() { inner() }
+outer()`},
+	{name: "undefined", src: `fn f() { return nope + 1 }
+f()`},
+	{name: "division by zero", src: `fn f(n) { return n / 0 }
+f(1)`},
+	{name: "too many arguments", src: `fn f(a) { return a }
+f(1, 2)`},
+	{name: "max steps", src: `fn f() {
+  let i = 0
+  while true { i++ }
+}
+f()`, opts: Options{MaxSteps: 500}},
+	{name: "loop guard", src: `fn f() {
+  for i in 0..100 { }
+}
+f()`, opts: Options{MaxLoopIterations: 10}},
+	{name: "call depth", src: `fn f(n) { return f(n + 1) }
+f(0)`, opts: Options{MaxCallDepth: 50}},
+}
+
+// The bytecode VM and the tree-walking evaluator must agree on output and
+// on errors, down to their positions and stack traces.
+func TestEngineParity(t *testing.T) {
+	tests := append([]parityTest(nil), parityTests...)
+	files, _ := filepath.Glob(filepath.Join("testdata", "bench", "*.os"))
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tests = append(tests, parityTest{name: file, src: string(src) + "\nprint(bench())"})
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			treeOpts, vmOpts := tt.opts, tt.opts
+			treeOpts.NoBytecode = true
+			treeOut, treeErr := run(tt.src, treeOpts)
+			vmOut, vmErr := run(tt.src, vmOpts)
+			if treeOut != vmOut {
+				t.Errorf("output differs:\ntree-walk: %q\nbytecode:  %q", treeOut, vmOut)
+			}
+			if errString(treeErr) != errString(vmErr) {
+				t.Errorf("error differs:\ntree-walk: %v\nbytecode:  %v", treeErr, vmErr)
+			}
+			if treeOut == "" && treeErr == nil {
+				t.Error("case produced no output and no error")
+			}
+		})
+	}
+}
+
+// errString describes err with the file and stack trace it reports.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	d := diagnose(err)[0]
+	return fmt.Sprintf("%s in %s %v", err, d.File, d.Stack)
+}
+
+// BenchmarkScripts times the bench function of each script in
+// testdata/bench on both engines:
+//
+//	go test ./internal/interpreter -run '^$' -bench Scripts
+func BenchmarkScripts(b *testing.B) {
+	files, _ := filepath.Glob(filepath.Join("testdata", "bench", "*.os"))
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".os")
+		for _, engine := range []struct {
+			name       string
+			noBytecode bool
+		}{{"tree-walk", true}, {"bytecode", false}} {
+			b.Run(name+"/"+engine.name, func(b *testing.B) {
+				in := New(Options{Output: io.Discard, NoBytecode: engine.noBytecode})
+				if err := in.RunFile(file, string(src)); err != nil {
+					b.Fatal(err)
+				}
+				// One untimed call reports script errors before timing.
+				if _, err := in.Call("bench"); err != nil {
+					b.Fatal(err)
+				}
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					in.Call("bench")
+				}
+			})
+		}
+	}
+}
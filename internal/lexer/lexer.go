@@ -0,0 +1,211 @@
+package lexer
+
+import (
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// Lexer turns Osun source text into a stream of tokens.
+type Lexer struct {
+	src  []rune
+	pos  int // index of the current rune
+	line int
+	col  int
+}
+
+// New creates a lexer over src.
+func New(src string) *Lexer {
+	return NewAt(src, token.Pos{Line: 1, Col: 1})
+}
+
+// NewAt creates a lexer over src, a fragment that begins at pos in the
+// enclosing file, such as the expression inside a template interpolation.
+func NewAt(src string, pos token.Pos) *Lexer {
+	return &Lexer{src: []rune(src), line: pos.Line, col: pos.Col}
+}
+
+// Tokenize lexes the whole input, always ending with an EOF token.
+func Tokenize(src string) []token.Token {
+	return tokenize(New(src))
+}
+
+// TokenizeAt lexes a source fragment that begins at pos.
+func TokenizeAt(src string, pos token.Pos) []token.Token {
+	return tokenize(NewAt(src, pos))
+}
+
+func tokenize(l *Lexer) []token.Token {
+	var toks []token.Token
+	for {
+		tok := l.Next()
+		toks = append(toks, tok)
+		if tok.Kind == token.EOF {
+			return toks
+		}
+	}
+}
+
+// Next returns the next token in the input.
+func (l *Lexer) Next() token.Token {
+	l.skipSpaceAndComments()
+
+	start := l.position()
+	if l.pos >= len(l.src) {
+		return token.Token{Kind: token.EOF, Pos: start}
+	}
+
+	ch := l.peek(0)
+	switch {
+	case isLetter(ch):
+		ident := l.readWhile(func(r rune) bool { return isLetter(r) || isDigit(r) })
+		return token.Token{Kind: token.LookupIdent(ident), Literal: ident, Pos: start}
+	case isDigit(ch):
+		return token.Token{Kind: token.NUMBER, Literal: l.readNumber(), Pos: start}
+	case ch == '"' || ch == '\'':
+		return l.readString(start)
+	case ch == '`':
+		return l.readTemplate(start)
+	}
+
+	if kind, lit, ok := l.readOperator(); ok {
+		return token.Token{Kind: kind, Literal: lit, Pos: start}
+	}
+
+	l.advance()
+	return token.Token{Kind: token.ILLEGAL, Literal: string(ch), Pos: start}
+}
+
+// -------------------- Scanning helpers ------------------------
+
+func (l *Lexer) peek(offset int) rune {
+	if l.pos+offset >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+offset]
+}
+
+func (l *Lexer) advance() rune {
+	ch := l.src[l.pos]
+	l.pos++
+	if ch == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return ch
+}
+
+func (l *Lexer) readWhile(pred func(rune) bool) string {
+	start := l.pos
+	for l.pos < len(l.src) && pred(l.src[l.pos]) {
+		l.advance()
+	}
+	return string(l.src[start:l.pos])
+}
+
+func (l *Lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		ch := l.peek(0)
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			l.advance()
+		case ch == '/' && l.peek(1) == '/':
+			l.readWhile(func(r rune) bool { return r != '\n' })
+		default:
+			return
+		}
+	}
+}
+
+// readNumber reads an integer such as 42 or a float such as 2.5 or 1e6.
+func (l *Lexer) readNumber() string {
+	start := l.pos
+	l.readWhile(isDigit)
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		l.advance()
+		l.readWhile(isDigit)
+	}
+	if e := l.peek(0); e == 'e' || e == 'E' {
+		sign := 0
+		if s := l.peek(1); s == '+' || s == '-' {
+			sign = 1
+		}
+		if isDigit(l.peek(1 + sign)) {
+			for i := 0; i <= sign; i++ {
+				l.advance()
+			}
+			l.readWhile(isDigit)
+		}
+	}
+	return string(l.src[start:l.pos])
+}
+
+// operators is ordered so that longer operators are matched first.
+var operators = []struct {
+	text string
+	kind token.Kind
+}{
+	{"==", token.EQ},
+	{"!=", token.NEQ},
+	{"<=", token.LTE},
+	{">=", token.GTE},
+	{"&&", token.AND},
+	{"||", token.OR},
+	{"..", token.DOTDOT},
+	{"++", token.INC},
+	{"--", token.DEC},
+	{"+=", token.PLUS_ASSIGN},
+	{"-=", token.MINUS_ASSIGN},
+	{"*=", token.STAR_ASSIGN},
+	{"/=", token.SLASH_ASSIGN},
+	{"=", token.ASSIGN},
+	{"+", token.PLUS},
+	{"-", token.MINUS},
+	{"*", token.STAR},
+	{"/", token.SLASH},
+	{"%", token.PERCENT},
+	{"<", token.LT},
+	{">", token.GT},
+	{"!", token.NOT},
+	{",", token.COMMA},
+	{".", token.DOT},
+	{":", token.COLON},
+	{";", token.SEMICOLON},
+	{"(", token.LPAREN},
+	{")", token.RPAREN},
+	{"{", token.LBRACE},
+	{"}", token.RBRACE},
+	{"[", token.LBRACKET},
+	{"]", token.RBRACKET},
+}
+
+func (l *Lexer) readOperator() (token.Kind, string, bool) {
+	for _, op := range operators {
+		if l.hasPrefix(op.text) {
+			for range op.text {
+				l.advance()
+			}
+			return op.kind, op.text, true
+		}
+	}
+	return token.ILLEGAL, "", false
+}
+
+func (l *Lexer) hasPrefix(s string) bool {
+	i := 0
+	for _, r := range s {
+		if l.peek(i) != r {
+			return false
+		}
+		i++
+	}
+	return true
+}
+
+func isLetter(ch rune) bool {
+	return ch == '_' || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
@@ -0,0 +1,115 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+func TestTokenize(t *testing.T) {
+	src := "let x = 1.5e3 // comment\n" +
+		"  if x >= 2 { print(\"a\\tb\", 'c') }\n" +
+		"3..5 x += 1 é"
+
+	want := []struct {
+		kind token.Kind
+		lit  string
+		line int
+		col  int
+	}{
+		{token.LET, "let", 1, 1},
+		{token.IDENT, "x", 1, 5},
+		{token.ASSIGN, "=", 1, 7},
+		{token.NUMBER, "1.5e3", 1, 9},
+		{token.IF, "if", 2, 3},
+		{token.IDENT, "x", 2, 6},
+		{token.GTE, ">=", 2, 8},
+		{token.NUMBER, "2", 2, 11},
+		{token.LBRACE, "{", 2, 13},
+		{token.IDENT, "print", 2, 15},
+		{token.LPAREN, "(", 2, 20},
+		{token.STRING, "a\tb", 2, 21},
+		{token.COMMA, ",", 2, 27},
+		{token.STRING, "c", 2, 29},
+		{token.RPAREN, ")", 2, 32},
+		{token.RBRACE, "}", 2, 34},
+		{token.NUMBER, "3", 3, 1},
+		{token.DOTDOT, "..", 3, 2},
+		{token.NUMBER, "5", 3, 4},
+		{token.IDENT, "x", 3, 6},
+		{token.PLUS_ASSIGN, "+=", 3, 8},
+		{token.NUMBER, "1", 3, 11},
+		{token.ILLEGAL, "é", 3, 13},
+		{token.EOF, "", 3, 14},
+	}
+
+	toks := Tokenize(src)
+	if len(toks) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %v", len(toks), len(want), toks)
+	}
+	for i, w := range want {
+		tok := toks[i]
+		if tok.Kind != w.kind || tok.Literal != w.lit || tok.Pos != (token.Pos{Line: w.line, Col: w.col}) {
+			t.Errorf("token %d = %v %q at %v, want %v %q at %d:%d",
+				i, tok.Kind, tok.Literal, tok.Pos, w.kind, w.lit, w.line, w.col)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	tests := []struct {
+		src  string
+		want []string
+	}{
+		{"42", []string{"42"}},
+		{"2.5", []string{"2.5"}},
+		{"1e6 2E-3 4e+2", []string{"1e6", "2E-3", "4e+2"}},
+		// A dot not followed by a digit is member access or a range.
+		{"1..3", []string{"1", "3"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, tok := range Tokenize(tt.src) {
+			if tok.Kind == token.NUMBER {
+				got = append(got, tok.Literal)
+			}
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q: got %q, want %q", tt.src, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestTemplateParts(t *testing.T) {
+	toks := Tokenize("\n  `v ${x + 1}!`")
+	tok := toks[0]
+	if tok.Kind != token.TEMPLATE || tok.Pos != (token.Pos{Line: 2, Col: 3}) {
+		t.Fatalf("got %v at %v", tok.Kind, tok.Pos)
+	}
+	want := []token.TemplatePart{
+		{Text: "v "},
+		{IsExpr: true, Text: "x + 1", Pos: token.Pos{Line: 2, Col: 8}},
+		{Text: "!"},
+	}
+	if len(tok.Parts) != len(want) {
+		t.Fatalf("got parts %+v", tok.Parts)
+	}
+	for i, p := range want {
+		if tok.Parts[i] != p {
+			t.Errorf("part %d = %+v, want %+v", i, tok.Parts[i], p)
+		}
+	}
+}
+
+func TestTokenizeAt(t *testing.T) {
+	toks := TokenizeAt("a\nb", token.Pos{Line: 4, Col: 10})
+	if toks[0].Pos != (token.Pos{Line: 4, Col: 10}) || toks[1].Pos != (token.Pos{Line: 5, Col: 1}) {
+		t.Errorf("positions %v %v", toks[0].Pos, toks[1].Pos)
+	}
+}
@@ -0,0 +1,167 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// readString lexes a "double" or 'single' quoted string, decoding escapes.
+func (l *Lexer) readString(start token.Pos) token.Token {
+	quote := l.advance()
+	var buf strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.peek(0) == '\n' {
+			return illegal(start, "unterminated string")
+		}
+		ch := l.peek(0)
+		if ch == quote {
+			l.advance()
+			return token.Token{Kind: token.STRING, Literal: buf.String(), Pos: start}
+		}
+		if ch == '\\' {
+			if err := l.readEscape(&buf); err != nil {
+				return illegal(start, err.Error())
+			}
+			continue
+		}
+		buf.WriteRune(l.advance())
+	}
+}
+
+// readTemplate lexes a `backtick` template string. Text may span lines; each
+// ${...} interpolation is kept as raw source for the parser to parse.
+func (l *Lexer) readTemplate(start token.Pos) token.Token {
+	l.advance() // opening backtick
+	tok := token.Token{Kind: token.TEMPLATE, Pos: start}
+	var buf strings.Builder
+	flush := func() {
+		if buf.Len() > 0 {
+			tok.Parts = append(tok.Parts, token.TemplatePart{Text: buf.String()})
+			buf.Reset()
+		}
+	}
+	for {
+		if l.pos >= len(l.src) {
+			return illegal(start, "unterminated template string")
+		}
+		switch ch := l.peek(0); {
+		case ch == '`':
+			l.advance()
+			flush()
+			return tok
+		case ch == '\\':
+			if err := l.readEscape(&buf); err != nil {
+				return illegal(start, err.Error())
+			}
+		case ch == '$' && l.peek(1) == '{':
+			flush()
+			l.advance()
+			l.advance()
+			pos := l.position()
+			src, ok := l.readInterpolation()
+			if !ok {
+				return illegal(pos, "unterminated ${ in template string")
+			}
+			tok.Parts = append(tok.Parts, token.TemplatePart{IsExpr: true, Text: src, Pos: pos})
+		default:
+			buf.WriteRune(l.advance())
+		}
+	}
+}
+
+// readInterpolation returns the source up to the } that closes a ${, stepping
+// over nested braces, strings and templates. The closing } is consumed.
+func (l *Lexer) readInterpolation() (string, bool) {
+	begin := l.pos
+	depth := 1
+	for l.pos < len(l.src) {
+		switch ch := l.peek(0); ch {
+		case '{':
+			depth++
+			l.advance()
+		case '}':
+			depth--
+			if depth == 0 {
+				src := string(l.src[begin:l.pos])
+				l.advance()
+				return src, true
+			}
+			l.advance()
+		case '"', '\'':
+			if tok := l.readString(l.position()); tok.Kind == token.ILLEGAL {
+				return "", false
+			}
+		case '`':
+			if tok := l.readTemplate(l.position()); tok.Kind == token.ILLEGAL {
+				return "", false
+			}
+		default:
+			l.advance()
+		}
+	}
+	return "", false
+}
+
+// readEscape decodes the escape sequence at the current backslash into buf.
+func (l *Lexer) readEscape(buf *strings.Builder) error {
+	l.advance() // backslash
+	if l.pos >= len(l.src) {
+		return fmt.Errorf("unterminated escape sequence")
+	}
+	ch := l.advance()
+	switch ch {
+	case 'n':
+		buf.WriteByte('\n')
+	case 't':
+		buf.WriteByte('\t')
+	case 'r':
+		buf.WriteByte('\r')
+	case '0':
+		buf.WriteByte(0)
+	case '\\', '"', '\'', '`', '$':
+		buf.WriteRune(ch)
+	case 'u':
+		r, err := l.readUnicodeEscape()
+		if err != nil {
+			return err
+		}
+		buf.WriteRune(r)
+	default:
+		return fmt.Errorf("unknown escape sequence \\%c", ch)
+	}
+	return nil
+}
+
+// readUnicodeEscape decodes the {hex} part of a \u{...} escape.
+func (l *Lexer) readUnicodeEscape() (rune, error) {
+	if l.peek(0) != '{' {
+		return 0, fmt.Errorf(`invalid unicode escape: expected \u{...}`)
+	}
+	l.advance()
+	hex := l.readWhile(isHexDigit)
+	if l.peek(0) != '}' || hex == "" || len(hex) > 6 {
+		return 0, fmt.Errorf(`invalid unicode escape \u{%s`, hex)
+	}
+	l.advance()
+	n, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(n)) {
+		return 0, fmt.Errorf(`invalid unicode code point \u{%s}`, hex)
+	}
+	return rune(n), nil
+}
+
+func (l *Lexer) position() token.Pos {
+	return token.Pos{Line: l.line, Col: l.col}
+}
+
+func illegal(pos token.Pos, msg string) token.Token {
+	return token.Token{Kind: token.ILLEGAL, Literal: msg, Pos: pos}
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
@@ -0,0 +1,631 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/lexer"
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// Parser is a recursive-descent parser over a token slice.
+type Parser struct {
+	toks       []token.Token
+	pos        int
+	loopDepth  int // > 0 while parsing a loop body
+	funcDepth  int // > 0 while parsing a function body
+	blockDepth int // > 0 while parsing any block
+	errors     ErrorList
+}
+
+// Parse parses a whole Osun source file. The returned error, if any, is an ErrorList.
+func Parse(src string) (*ast.Program, error) {
+	p := &Parser{toks: lexer.Tokenize(src)}
+	prog := p.parseProgram()
+	return prog, p.errors.Err()
+}
+
+// bailout is raised by fail to unwind to the nearest statement boundary.
+type bailout struct{}
+
+func (p *Parser) parseProgram() *ast.Program {
+	prog := &ast.Program{}
+	for !p.at(token.EOF) {
+		if stmt := p.parseStatementSafe(); stmt != nil {
+			prog.Statements = append(prog.Statements, stmt)
+		}
+	}
+	return prog
+}
+
+// parseStatementSafe parses one statement and, on a syntax error, skips to
+// the next line so that later errors can still be reported.
+func (p *Parser) parseStatementSafe() (stmt ast.Statement) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			stmt = nil
+			p.synchronize()
+		}
+	}()
+	return p.parseStatement()
+}
+
+// synchronize skips the rest of the line on which the last error occurred.
+func (p *Parser) synchronize() {
+	line := p.errors[len(p.errors)-1].Pos.Line
+	for !p.at(token.EOF) && p.cur().Pos.Line <= line {
+		p.next()
+	}
+}
+
+// -------------------- Token helpers ------------------------
+
+func (p *Parser) cur() token.Token {
+	return p.toks[p.pos]
+}
+
+func (p *Parser) peek() token.Token {
+	if p.pos+1 < len(p.toks) {
+		return p.toks[p.pos+1]
+	}
+	return p.toks[len(p.toks)-1]
+}
+
+func (p *Parser) at(kind token.Kind) bool {
+	return p.cur().Kind == kind
+}
+
+func (p *Parser) next() token.Token {
+	tok := p.cur()
+	if tok.Kind != token.EOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *Parser) accept(kind token.Kind) bool {
+	if p.at(kind) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) expect(kind token.Kind) token.Token {
+	if !p.at(kind) {
+		p.fail(p.cur().Pos, "expected %s, found %s", kind, p.cur())
+	}
+	return p.next()
+}
+
+func (p *Parser) fail(pos token.Pos, format string, args ...any) {
+	p.errors = append(p.errors, &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+	panic(bailout{})
+}
+
+// -------------------- Statements ------------------------
+
+func (p *Parser) parseStatement() ast.Statement {
+	var stmt ast.Statement
+	switch p.cur().Kind {
+	case token.LET, token.CONST:
+		stmt = p.parseLet()
+	case token.IF:
+		stmt = p.parseIf()
+	case token.WHILE:
+		stmt = p.parseWhile()
+	case token.FOR:
+		stmt = p.parseForIn()
+	case token.BREAK, token.CONTINUE:
+		stmt = p.parseLoopControl()
+	case token.FN:
+		if p.peek().Kind == token.IDENT {
+			stmt = p.parseFunctionStatement()
+		} else {
+			stmt = p.parseSimpleStatement()
+		}
+	case token.RETURN:
+		stmt = p.parseReturn()
+	case token.TRY:
+		stmt = p.parseTry()
+	case token.THROW:
+		stmt = p.parseThrow()
+	case token.IMPORT:
+		stmt = p.parseImport()
+	case token.EXPORT:
+		stmt = p.parseExport()
+	case token.LBRACE:
+		stmt = p.parseBlock()
+	default:
+		stmt = p.parseSimpleStatement()
+	}
+	p.accept(token.SEMICOLON)
+	return stmt
+}
+
+// parseSimpleStatement parses an expression statement, an assignment or an
+// increment/decrement.
+func (p *Parser) parseSimpleStatement() ast.Statement {
+	expr := p.parseExpression()
+	switch p.cur().Kind {
+	case token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN, token.STAR_ASSIGN, token.SLASH_ASSIGN:
+		op := p.next()
+		p.checkAssignable(expr, op)
+		return &ast.AssignStatement{Target: expr, OpPos: op.Pos, Op: op.Kind, Value: p.parseExpression()}
+	case token.INC, token.DEC:
+		op := p.next()
+		p.checkAssignable(expr, op)
+		return &ast.IncDecStatement{Target: expr, OpPos: op.Pos, Op: op.Kind}
+	}
+	return &ast.ExpressionStatement{Expr: expr}
+}
+
+func (p *Parser) checkAssignable(expr ast.Expression, op token.Token) {
+	switch expr.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.MemberExpression:
+	default:
+		p.fail(op.Pos, "cannot apply %s to this expression", op.Kind)
+	}
+}
+
+func (p *Parser) parseLet() *ast.LetStatement {
+	let := p.next() // let or const
+	name := p.parseIdentifier()
+	p.expect(token.ASSIGN)
+	return &ast.LetStatement{Let: let.Pos, Const: let.Kind == token.CONST, Name: name, Value: p.parseExpression()}
+}
+
+func (p *Parser) parseIf() *ast.IfStatement {
+	ifTok := p.expect(token.IF)
+	stmt := &ast.IfStatement{If: ifTok.Pos}
+	stmt.Condition = p.parseExpression()
+	stmt.Consequence = p.parseBlock()
+	if p.accept(token.ELSE) {
+		if p.at(token.IF) {
+			stmt.Alternative = p.parseIf()
+		} else {
+			stmt.Alternative = p.parseBlock()
+		}
+	}
+	return stmt
+}
+
+func (p *Parser) parseWhile() *ast.WhileStatement {
+	while := p.expect(token.WHILE)
+	stmt := &ast.WhileStatement{While: while.Pos}
+	stmt.Condition = p.parseExpression()
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+func (p *Parser) parseForIn() *ast.ForInStatement {
+	forTok := p.expect(token.FOR)
+	stmt := &ast.ForInStatement{For: forTok.Pos}
+	stmt.Value = p.parseIdentifier()
+	if p.accept(token.COMMA) {
+		stmt.Key = stmt.Value
+		stmt.Value = p.parseIdentifier()
+	}
+	p.expect(token.IN)
+	stmt.Iterable = p.parseExpression()
+	if p.at(token.DOTDOT) {
+		op := p.next()
+		stmt.Iterable = &ast.RangeExpression{OpPos: op.Pos, Start: stmt.Iterable, End: p.parseExpression()}
+	}
+	stmt.Body = p.parseLoopBody()
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+	return p.parseBlock()
+}
+
+func (p *Parser) parseLoopControl() ast.Statement {
+	tok := p.next()
+	if p.loopDepth == 0 {
+		p.fail(tok.Pos, "%s outside of a loop", tok.Kind)
+	}
+	if tok.Kind == token.BREAK {
+		return &ast.BreakStatement{Break: tok.Pos}
+	}
+	return &ast.ContinueStatement{Continue: tok.Pos}
+}
+
+func (p *Parser) parseFunctionStatement() *ast.FunctionStatement {
+	fn := p.parseFunctionLiteral()
+	return &ast.FunctionStatement{Name: &ast.Identifier{NamePos: fn.Fn, Name: fn.Name}, Func: fn}
+}
+
+// parseFunctionLiteral parses `fn [name](a, b) { ... }`.
+func (p *Parser) parseFunctionLiteral() *ast.FunctionLiteral {
+	fnTok := p.expect(token.FN)
+	fn := &ast.FunctionLiteral{Fn: fnTok.Pos}
+	if p.at(token.IDENT) {
+		fn.Name = p.parseIdentifier().Name
+	}
+	p.expect(token.LPAREN)
+	for !p.at(token.RPAREN) {
+		fn.Params = append(fn.Params, p.parseIdentifier())
+		if !p.accept(token.COMMA) {
+			break
+		}
+	}
+	p.expect(token.RPAREN)
+
+	// Loops do not extend into a nested function body.
+	outerLoops := p.loopDepth
+	p.loopDepth = 0
+	p.funcDepth++
+	defer func() {
+		p.loopDepth = outerLoops
+		p.funcDepth--
+	}()
+	fn.Body = p.parseBlock()
+	return fn
+}
+
+func (p *Parser) parseReturn() *ast.ReturnStatement {
+	ret := p.expect(token.RETURN)
+	if p.funcDepth == 0 {
+		p.fail(ret.Pos, "return outside of a function")
+	}
+	stmt := &ast.ReturnStatement{Return: ret.Pos}
+	// A value must start on the same line as the return keyword.
+	next := p.cur()
+	if next.Pos.Line == ret.Pos.Line && next.Kind != token.RBRACE &&
+		next.Kind != token.SEMICOLON && next.Kind != token.EOF {
+		stmt.Value = p.parseExpression()
+	}
+	return stmt
+}
+
+// parseTry parses `try { } catch (e) { } finally { }`. The catch parameter
+// may be written with or without parentheses, or left out entirely.
+func (p *Parser) parseTry() *ast.TryStatement {
+	try := p.expect(token.TRY)
+	stmt := &ast.TryStatement{Try: try.Pos, Body: p.parseBlock()}
+	if p.accept(token.CATCH) {
+		if p.accept(token.LPAREN) {
+			stmt.CatchParam = p.parseIdentifier()
+			p.expect(token.RPAREN)
+		} else if p.at(token.IDENT) {
+			stmt.CatchParam = p.parseIdentifier()
+		}
+		stmt.Catch = p.parseBlock()
+	}
+	if p.accept(token.FINALLY) {
+		stmt.Finally = p.parseBlock()
+	}
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.fail(try.Pos, "try without catch or finally")
+	}
+	return stmt
+}
+
+func (p *Parser) parseThrow() *ast.ThrowStatement {
+	throw := p.expect(token.THROW)
+	if p.cur().Pos.Line != throw.Pos.Line {
+		p.fail(throw.Pos, "throw requires a value on the same line")
+	}
+	return &ast.ThrowStatement{Throw: throw.Pos, Value: p.parseExpression()}
+}
+
+// parseImport parses `import { a, b as c } from "path"` or `import "path"`.
+func (p *Parser) parseImport() *ast.ImportStatement {
+	imp := p.expect(token.IMPORT)
+	p.checkTopLevel(imp)
+	stmt := &ast.ImportStatement{Import: imp.Pos}
+	if p.accept(token.LBRACE) {
+		for !p.at(token.RBRACE) {
+			name := &ast.ImportName{Name: p.parseIdentifier()}
+			if p.acceptWord("as") {
+				name.Alias = p.parseIdentifier()
+			}
+			stmt.Names = append(stmt.Names, name)
+			if !p.accept(token.COMMA) {
+				break
+			}
+		}
+		p.expect(token.RBRACE)
+		if !p.acceptWord("from") {
+			p.fail(p.cur().Pos, "expected from, found %s", p.cur())
+		}
+	}
+	path := p.expect(token.STRING)
+	stmt.Path = &ast.StringLiteral{ValuePos: path.Pos, Value: path.Literal}
+	return stmt
+}
+
+// parseExport parses `export` followed by a let, const or fn declaration.
+func (p *Parser) parseExport() *ast.ExportStatement {
+	exp := p.expect(token.EXPORT)
+	p.checkTopLevel(exp)
+	stmt := &ast.ExportStatement{Export: exp.Pos}
+	switch {
+	case p.at(token.LET) || p.at(token.CONST):
+		stmt.Decl = p.parseLet()
+	case p.at(token.FN) && p.peek().Kind == token.IDENT:
+		stmt.Decl = p.parseFunctionStatement()
+	default:
+		p.fail(p.cur().Pos, "export must be followed by let, const or a named fn")
+	}
+	return stmt
+}
+
+func (p *Parser) checkTopLevel(tok token.Token) {
+	if p.blockDepth > 0 {
+		p.fail(tok.Pos, "%s is only allowed at the top level of a file", tok.Kind)
+	}
+}
+
+// acceptWord consumes an identifier used as a contextual keyword, such as
+// from and as in imports, which stay usable as ordinary names elsewhere.
+func (p *Parser) acceptWord(word string) bool {
+	if p.at(token.IDENT) && p.cur().Literal == word {
+		p.next()
+		return true
+	}
+	return false
+}
+
+func (p *Parser) parseBlock() *ast.BlockStatement {
+	lbrace := p.expect(token.LBRACE)
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+	block := &ast.BlockStatement{LBrace: lbrace.Pos}
+	for !p.at(token.RBRACE) {
+		if p.at(token.EOF) {
+			p.fail(lbrace.Pos, "unclosed block: missing }")
+		}
+		block.Statements = append(block.Statements, p.parseStatement())
+	}
+	p.expect(token.RBRACE)
+	return block
+}
+
+// -------------------- Expressions ------------------------
+
+// Precedence, lowest to highest:
+//
+//	||
+//	&&
+//	== !=
+//	< <= > >=
+//	+ -
+//	* / %
+//	unary - !
+//	call, member access, indexing
+func (p *Parser) parseExpression() ast.Expression {
+	return p.parseOr()
+}
+
+func (p *Parser) parseOr() ast.Expression {
+	left := p.parseAnd()
+	for p.at(token.OR) {
+		op := p.next()
+		right := p.parseAnd()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseAnd() ast.Expression {
+	left := p.parseEquality()
+	for p.at(token.AND) {
+		op := p.next()
+		right := p.parseEquality()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseEquality() ast.Expression {
+	left := p.parseRelational()
+	for p.at(token.EQ) || p.at(token.NEQ) {
+		op := p.next()
+		right := p.parseRelational()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseRelational() ast.Expression {
+	left := p.parseAdditive()
+	for p.at(token.LT) || p.at(token.LTE) || p.at(token.GT) || p.at(token.GTE) {
+		op := p.next()
+		right := p.parseAdditive()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseAdditive() ast.Expression {
+	left := p.parseMultiplicative()
+	for p.at(token.PLUS) || p.at(token.MINUS) {
+		op := p.next()
+		right := p.parseMultiplicative()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseMultiplicative() ast.Expression {
+	left := p.parseUnary()
+	for p.at(token.STAR) || p.at(token.SLASH) || p.at(token.PERCENT) {
+		op := p.next()
+		right := p.parseUnary()
+		left = &ast.BinaryExpression{OpPos: op.Pos, Op: op.Kind, Left: left, Right: right}
+	}
+	return left
+}
+
+func (p *Parser) parseUnary() ast.Expression {
+	if p.at(token.MINUS) || p.at(token.NOT) {
+		op := p.next()
+		return &ast.UnaryExpression{OpPos: op.Pos, Op: op.Kind, Operand: p.parseUnary()}
+	}
+	return p.parsePostfix()
+}
+
+func (p *Parser) parsePostfix() ast.Expression {
+	expr := p.parsePrimary()
+	for {
+		switch p.cur().Kind {
+		case token.LPAREN:
+			lparen := p.next()
+			expr = &ast.CallExpression{Lparen: lparen.Pos, Callee: expr, Args: p.parseArgs()}
+		case token.DOT:
+			dot := p.next()
+			expr = &ast.MemberExpression{Dot: dot.Pos, Object: expr, Property: p.parseIdentifier()}
+		case token.LBRACKET:
+			lbrack := p.next()
+			index := p.parseExpression()
+			p.expect(token.RBRACKET)
+			expr = &ast.IndexExpression{Lbrack: lbrack.Pos, Object: expr, Index: index}
+		default:
+			return expr
+		}
+	}
+}
+
+// parseArgs parses a comma-separated argument list after the opening paren.
+func (p *Parser) parseArgs() []ast.Expression {
+	return p.parseExpressionList(token.RPAREN)
+}
+
+// parseExpressionList parses comma-separated expressions up to and including
+// the closing token. A trailing comma is allowed.
+func (p *Parser) parseExpressionList(end token.Kind) []ast.Expression {
+	var list []ast.Expression
+	for !p.at(end) {
+		list = append(list, p.parseExpression())
+		if !p.accept(token.COMMA) {
+			break
+		}
+	}
+	p.expect(end)
+	return list
+}
+
+func (p *Parser) parsePrimary() ast.Expression {
+	tok := p.cur()
+	switch tok.Kind {
+	case token.IDENT:
+		return p.parseIdentifier()
+	case token.NUMBER:
+		p.next()
+		return &ast.NumberLiteral{ValuePos: tok.Pos, Raw: tok.Literal, Value: p.parseNumber(tok)}
+	case token.STRING:
+		p.next()
+		return &ast.StringLiteral{ValuePos: tok.Pos, Value: tok.Literal}
+	case token.TEMPLATE:
+		p.next()
+		return p.parseTemplate(tok)
+	case token.TRUE, token.FALSE:
+		p.next()
+		return &ast.BooleanLiteral{ValuePos: tok.Pos, Value: tok.Kind == token.TRUE}
+	case token.NULL:
+		p.next()
+		return &ast.NullLiteral{ValuePos: tok.Pos}
+	case token.FN:
+		return p.parseFunctionLiteral()
+	case token.LBRACKET:
+		p.next()
+		return &ast.ArrayLiteral{Lbrack: tok.Pos, Elements: p.parseExpressionList(token.RBRACKET)}
+	case token.LBRACE:
+		return p.parseObjectLiteral()
+	case token.LPAREN:
+		p.next()
+		expr := p.parseExpression()
+		p.expect(token.RPAREN)
+		return expr
+	case token.ILLEGAL:
+		p.fail(tok.Pos, "%s", tok.Literal)
+	}
+	p.fail(tok.Pos, "unexpected %s", tok)
+	return nil
+}
+
+// parseNumber returns an int64 for integer literals and a float64 for
+// literals with a fraction or exponent.
+func (p *Parser) parseNumber(tok token.Token) any {
+	if !strings.ContainsAny(tok.Literal, ".eE") {
+		n, err := strconv.ParseInt(tok.Literal, 10, 64)
+		if err != nil {
+			p.fail(tok.Pos, "integer literal %s is out of range", tok.Literal)
+		}
+		return n
+	}
+	f, err := strconv.ParseFloat(tok.Literal, 64)
+	if err != nil {
+		p.fail(tok.Pos, "invalid number %q", tok.Literal)
+	}
+	return f
+}
+
+// parseTemplate turns the parts of a template token into text literals and
+// the parsed expressions of each ${...} interpolation.
+func (p *Parser) parseTemplate(tok token.Token) *ast.TemplateLiteral {
+	tmpl := &ast.TemplateLiteral{Backtick: tok.Pos}
+	for _, part := range tok.Parts {
+		if !part.IsExpr {
+			tmpl.Parts = append(tmpl.Parts, &ast.StringLiteral{ValuePos: part.Pos, Value: part.Text})
+			continue
+		}
+		tmpl.Parts = append(tmpl.Parts, p.parseEmbedded(part.Text, part.Pos))
+	}
+	return tmpl
+}
+
+// parseEmbedded parses src, a fragment starting at pos, as a single expression.
+func (p *Parser) parseEmbedded(src string, pos token.Pos) ast.Expression {
+	sub := &Parser{toks: lexer.TokenizeAt(src, pos), loopDepth: p.loopDepth, funcDepth: p.funcDepth}
+	defer func() {
+		if r := recover(); r != nil {
+			p.errors = append(p.errors, sub.errors...)
+			panic(r)
+		}
+	}()
+	if sub.at(token.EOF) {
+		sub.fail(pos, "empty ${} in template string")
+	}
+	expr := sub.parseExpression()
+	if !sub.at(token.EOF) {
+		sub.fail(sub.cur().Pos, "unexpected %s in template expression", sub.cur())
+	}
+	return expr
+}
+
+// parseObjectLiteral parses `{ key: value, ... }`. Keys are identifiers or
+// string literals; a trailing comma is allowed.
+func (p *Parser) parseObjectLiteral() *ast.ObjectLiteral {
+	lbrace := p.expect(token.LBRACE)
+	obj := &ast.ObjectLiteral{Lbrace: lbrace.Pos}
+	for !p.at(token.RBRACE) {
+		key := p.cur()
+		if key.Kind != token.IDENT && key.Kind != token.STRING {
+			p.fail(key.Pos, "expected object key, found %s", key)
+		}
+		p.next()
+		p.expect(token.COLON)
+		obj.Keys = append(obj.Keys, key.Literal)
+		obj.Values = append(obj.Values, p.parseExpression())
+		if !p.accept(token.COMMA) {
+			break
+		}
+	}
+	p.expect(token.RBRACE)
+	return obj
+}
+
+func (p *Parser) parseIdentifier() *ast.Identifier {
+	tok := p.expect(token.IDENT)
+	return &ast.Identifier{NamePos: tok.Pos, Name: tok.Literal}
+}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/intellidevelopers/osun-lang/internal/ast"
+	"github.com/intellidevelopers/osun-lang/internal/token"
+)
+
+// checkErrors parses each source and compares the error it reports.
+func checkErrors(t *testing.T, tests []struct{ src, want string }) {
+	t.Helper()
+	for _, tt := range tests {
+		_, err := Parse(tt.src)
+		if err == nil {
+			t.Errorf("%q: no error, want %q", tt.src, tt.want)
+			continue
+		}
+		if err.Error() != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	checkErrors(t, []struct{ src, want string }{
+		{"break\n", "1:1: break outside of a loop"},
+		{"return 1", "1:1: return outside of a function"},
+		{"\"unterminated", "1:1: unterminated string"},
+		{"let a = [1, 2", "1:14: expected ], found EOF"},
+		{"x + 1 = 3", "1:7: cannot apply = to this expression"},
+	})
+}
+
+// After an error the parser skips to the next line and keeps going, so one
+// run reports every broken line and still parses the good ones.
+func TestErrorRecovery(t *testing.T) {
+	prog, err := Parse("let = 5\nlet y = 2\nprint(y +)\nlet z = y\n")
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 2 {
+		t.Fatalf("got %v, want two errors", err)
+	}
+	if list[0].Pos != (token.Pos{Line: 1, Col: 5}) || list[1].Pos != (token.Pos{Line: 3, Col: 10}) {
+		t.Errorf("error positions %v, %v", list[0].Pos, list[1].Pos)
+	}
+	var names []string
+	for _, s := range prog.Statements {
+		if let, ok := s.(*ast.LetStatement); ok {
+			names = append(names, let.Name.Name)
+		}
+	}
+	if len(names) != 2 || names[0] != "y" || names[1] != "z" {
+		t.Errorf("recovered statements %v, want [y z]", names)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	prog, err := Parse("let v = 1 + 2 * 3 == 7 && !false || x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := render(prog.Statements[0].(*ast.LetStatement).Value)
+	want := "((((1 + (2 * 3)) == 7) && (!false)) || x)"
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// render prints an expression with full parentheses.
+func render(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.BinaryExpression:
+		return "(" + render(e.Left) + " " + e.Op.String() + " " + render(e.Right) + ")"
+	case *ast.UnaryExpression:
+		return "(" + e.Op.String() + render(e.Operand) + ")"
+	case *ast.Identifier:
+		return e.Name
+	case *ast.NumberLiteral:
+		return fmt.Sprint(e.Value)
+	case *ast.BooleanLiteral:
+		if e.Value {
+			return "true"
+		}
+		return "false"
+	}
+	return "?"
+}
+
+func TestTryThrow(t *testing.T) {
+	prog, err := Parse(`try { throw "x" } catch (e) { } finally { }
+try { } finally { }
+try { } catch { }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := prog.Statements[0].(*ast.TryStatement)
+	if full.CatchParam == nil || full.CatchParam.Name != "e" || full.Catch == nil || full.Finally == nil {
+		t.Errorf("try/catch/finally parsed as %+v", full)
+	}
+	if _, ok := full.Body.Statements[0].(*ast.ThrowStatement); !ok {
+		t.Errorf("try body is %T", full.Body.Statements[0])
+	}
+	if s := prog.Statements[1].(*ast.TryStatement); s.Catch != nil || s.Finally == nil {
+		t.Errorf("try/finally parsed as %+v", s)
+	}
+	if s := prog.Statements[2].(*ast.TryStatement); s.CatchParam != nil || s.Catch == nil {
+		t.Errorf("bare catch parsed as %+v", s)
+	}
+}
+
+func TestTryThrowErrors(t *testing.T) {
+	checkErrors(t, []struct{ src, want string }{
+		{"throw\n1", "1:1: throw requires a value on the same line"},
+		{"try { }", "1:1: try without catch or finally"},
+		{"try x", `1:5: expected {, found identifier "x"`},
+		{"try { } catch (e { }", "1:18: expected ), found {"},
+	})
+}
+
+func TestNumberLiterals(t *testing.T) {
+	prog, err := Parse("let a = 7\nlet b = 7.0\nlet c = 1e3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{int64(7), 7.0, 1000.0}
+	for i, s := range prog.Statements {
+		got := s.(*ast.LetStatement).Value.(*ast.NumberLiteral).Value
+		if got != want[i] {
+			t.Errorf("literal %d = %#v, want %#v", i, got, want[i])
+		}
+	}
+}
+
+func TestImportExport(t *testing.T) {
+	prog, err := Parse("import { a, b as c } from \"./lib\"\nimport \"./setup\"\nexport fn f() {}\nexport const k = 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	imp := prog.Statements[0].(*ast.ImportStatement)
+	if imp.Path.Value != "./lib" || len(imp.Names) != 2 || imp.Names[0].Alias != nil || imp.Names[1].Alias.Name != "c" {
+		t.Errorf("import parsed as %+v", imp)
+	}
+	if bare := prog.Statements[1].(*ast.ImportStatement); bare.Path.Value != "./setup" || len(bare.Names) != 0 {
+		t.Errorf("side-effect import parsed as %+v", bare)
+	}
+	if _, ok := prog.Statements[2].(*ast.ExportStatement).Decl.(*ast.FunctionStatement); !ok {
+		t.Errorf("export fn parsed as %T", prog.Statements[2].(*ast.ExportStatement).Decl)
+	}
+	if let, ok := prog.Statements[3].(*ast.ExportStatement).Decl.(*ast.LetStatement); !ok || !let.Const {
+		t.Errorf("export const parsed as %+v", prog.Statements[3].(*ast.ExportStatement).Decl)
+	}
+}
+
+func TestImportExportErrors(t *testing.T) {
+	checkErrors(t, []struct{ src, want string }{
+		{"fn f() { import \"x\" }", "1:10: import is only allowed at the top level of a file"},
+		{"export x", "1:8: export must be followed by let, const or a named fn"},
+		{"if true { export let x = 1 }", "1:11: export is only allowed at the top level of a file"},
+		{"import { a } \"x\"", `1:14: expected from, found string "x"`},
+	})
+}
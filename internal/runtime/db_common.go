@@ -1,38 +1,45 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
 
-// DBInsert attempts to insert into the first available database (Mongo > MySQL > Postgres)
-func DBInsert(target, jsonStr string) error {
+// dbBackend is a database DBInsert can write to.
+type dbBackend struct {
+	name   string
+	active func() bool
+	insert func(ctx context.Context, target, jsonStr string) error
+}
+
+// insertBackends lists the databases DBInsert tries, in order.
+var insertBackends = []dbBackend{
+	{"MongoDB", func() bool { return mongoClient != nil }, DBInsertMongo},
+	{"MySQL", func() bool { return mysqlDB != nil }, DBInsertMySQL},
+	{"Postgres", func() bool { return pgDB != nil }, DBInsertPostgres},
+}
+
+// DBInsert attempts to insert into the first available database (Mongo > MySQL > Postgres).
+// It gives up once ctx is done rather than trying the next database.
+func DBInsert(ctx context.Context, target, jsonStr string) error {
 	var parsed map[string]interface{}
 	if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
 		return fmt.Errorf("invalid json: %w", err)
 	}
 
-	if mongoClient != nil {
-		if err := DBInsertMongo(target, jsonStr); err == nil {
-			fmt.Println("[osun] MongoDB insert success")
-			return nil
+	for _, b := range insertBackends {
+		if !b.active() {
+			continue
 		}
-	}
-
-	if mysqlDB != nil {
-		if err := DBInsertMySQL(target, jsonStr); err == nil {
-			fmt.Println("[osun] MySQL insert success")
+		if err := b.insert(ctx, target, jsonStr); err == nil {
+			fmt.Printf("[osun] %s insert success\n", b.name)
 			return nil
 		}
-	}
-
-	if pgDB != nil {
-		if err := DBInsertPostgres(target, jsonStr); err == nil {
-			fmt.Println("[osun] Postgres insert success")
-			return nil
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 	}
-
 	return fmt.Errorf("no active database connection")
 }
 
@@ -0,0 +1,64 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// useBackends replaces the databases DBInsert tries for one test. Each
+// backend counts its attempts in tries and fails when fail returns an error.
+func useBackends(t *testing.T, fails ...func() error) []int {
+	tries := make([]int, len(fails))
+	old := insertBackends
+	t.Cleanup(func() { insertBackends = old })
+	insertBackends = nil
+	for i, fail := range fails {
+		insertBackends = append(insertBackends, dbBackend{
+			name:   "fake",
+			active: func() bool { return true },
+			insert: func(context.Context, string, string) error {
+				tries[i]++
+				return fail()
+			},
+		})
+	}
+	return tries
+}
+
+func TestDBInsertFallsBack(t *testing.T) {
+	tries := useBackends(t,
+		func() error { return errors.New("table missing") },
+		func() error { return nil })
+	if err := DBInsert(context.Background(), "users", `{"name":"Ada"}`); err != nil {
+		t.Fatal(err)
+	}
+	if tries[0] != 1 || tries[1] != 1 {
+		t.Errorf("tries %v, want [1 1]", tries)
+	}
+}
+
+func TestDBInsertStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tries := useBackends(t,
+		func() error {
+			cancel()
+			return errors.New("interrupted")
+		},
+		func() error { return nil })
+	err := DBInsert(ctx, "users", `{"name":"Ada"}`)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error %v, want %v", err, context.Canceled)
+	}
+	if tries[0] != 1 || tries[1] != 0 {
+		t.Errorf("tries %v, want the second backend not tried", tries)
+	}
+}
+
+func TestDBInsertNoDatabase(t *testing.T) {
+	useBackends(t)
+	if err := DBInsert(context.Background(), "users", `{}`); err == nil || err.Error() != "no active database connection" {
+		t.Errorf("error %v", err)
+	}
+}
@@ -37,7 +37,7 @@ func InitMongoFromEnv() {
 }
 
 // DBInsertMongo inserts a JSON object into the named collection in database "osun".
-func DBInsertMongo(collection string, jsonStr string) error {
+func DBInsertMongo(ctx context.Context, collection string, jsonStr string) error {
 	if mongoClient == nil {
 		return fmt.Errorf("mongo not configured")
 	}
@@ -45,7 +45,7 @@ func DBInsertMongo(collection string, jsonStr string) error {
 	if err := json.Unmarshal([]byte(jsonStr), &doc); err != nil {
 		return fmt.Errorf("invalid json: %w", err)
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 	_, err := mongoClient.Database("osun").Collection(collection).InsertOne(ctx, doc)
 	if err != nil {
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -37,7 +38,7 @@ func InitMySQLFromEnv() {
 // DBInsertMySQL inserts jsonStr (JSON object) into `table`.
 // It unmarshals JSON into map[string]interface{} and maps keys -> columns.
 // NOTE: column names must exist in the table. This function uses `?` placeholders.
-func DBInsertMySQL(table, jsonStr string) error {
+func DBInsertMySQL(ctx context.Context, table, jsonStr string) error {
 	if mysqlDB == nil {
 		return fmt.Errorf("mysql not configured")
 	}
@@ -64,7 +65,7 @@ func DBInsertMySQL(table, jsonStr string) error {
 		strings.Join(cols, ","),
 		strings.Join(placeholders, ","))
 
-	_, err := mysqlDB.Exec(query, vals...)
+	_, err := mysqlDB.ExecContext(ctx, query, vals...)
 	if err != nil {
 		return fmt.Errorf("mysql exec error: %w", err)
 	}
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -34,7 +35,7 @@ func InitPostgresFromEnv() {
 }
 
 // DBInsertPostgres inserts jsonStr into table using numbered placeholders $1, $2...
-func DBInsertPostgres(table, jsonStr string) error {
+func DBInsertPostgres(ctx context.Context, table, jsonStr string) error {
 	if pgDB == nil {
 		return fmt.Errorf("postgres not configured")
 	}
@@ -62,7 +63,7 @@ func DBInsertPostgres(table, jsonStr string) error {
 		strings.Join(cols, ","),
 		strings.Join(placeholders, ","))
 
-	_, err := pgDB.Exec(query, vals...)
+	_, err := pgDB.ExecContext(ctx, query, vals...)
 	if err != nil {
 		return fmt.Errorf("postgres exec error: %w", err)
 	}
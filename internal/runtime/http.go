@@ -1,11 +1,15 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -41,27 +45,81 @@ func (s *OsunServer) Handle(method, path string, handler http.HandlerFunc) {
 	s.routes[method][path] = handler
 }
 
-// Start server
-func (s *OsunServer) Listen() {
+// Start server. Only the first Listen on a port starts a server, and it
+// blocks while serving. Listening again on that port within the same
+// ReloadScope, as a script re-run by -watch does, swaps the new routes into
+// the running server and returns; any other Listen on it fails.
+func (s *OsunServer) Listen(ctx context.Context) error {
+	l, running, err := mount(s.port, s.handler(), reloadScopeOf(ctx))
+	if err != nil {
+		return err
+	}
+	if running {
+		fmt.Printf("♻️  Osun Server routes reloaded on port %d\n", s.port)
+		return nil
+	}
+
+	addr := fmt.Sprintf(":%d", s.port)
+	fmt.Printf("🚀 Osun Server running at http://localhost%s\n", addr)
+	err = http.ListenAndServe(addr, l)
+	unmount(s.port, l)
+	return fmt.Errorf("server on port %d: %w", s.port, err)
+}
+
+// reloadScope identifies a series of runs of one script; see ReloadScope.
+type reloadScope struct{ _ byte }
+
+type reloadScopeKey struct{}
+
+// ReloadScope returns a context for running the same script repeatedly,
+// as the file watcher does after each change. A Listen made under it on a
+// port that an earlier Listen under the same scope is serving reloads that
+// server's routes instead of failing because the port is in use.
+func ReloadScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reloadScopeKey{}, &reloadScope{})
+}
+
+func reloadScopeOf(ctx context.Context) *reloadScope {
+	scope, _ := ctx.Value(reloadScopeKey{}).(*reloadScope)
+	return scope
+}
+
+// handler returns a handler serving the registered routes, each wrapped in
+// the middleware.
+func (s *OsunServer) handler() http.Handler {
+	byPath := map[string]map[string]http.HandlerFunc{}
 	for method, paths := range s.routes {
 		for path, handler := range paths {
 			h := handler
 			for i := len(s.middlewares) - 1; i >= 0; i-- {
 				h = s.middlewares[i](h)
 			}
-			http.HandleFunc(path, methodHandler(method, h))
+			if byPath[path] == nil {
+				byPath[path] = map[string]http.HandlerFunc{}
+			}
+			byPath[path][method] = h
 			fmt.Printf("[%s] %s registered\n", method, path)
 		}
 	}
-
-	addr := fmt.Sprintf(":%d", s.port)
-	fmt.Printf("🚀 Osun Server running at http://localhost%s\n", addr)
-	http.ListenAndServe(addr, nil)
+	mux := http.NewServeMux()
+	for path, methods := range byPath {
+		mux.HandleFunc(path, methodHandler(methods))
+	}
+	return mux
 }
 
-func methodHandler(method string, handler http.HandlerFunc) http.HandlerFunc {
+// methodHandler dispatches a request on one path to the handler for its
+// method.
+func methodHandler(methods map[string]http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != method {
+		handler, ok := methods[r.Method]
+		if !ok {
+			allowed := make([]string, 0, len(methods))
+			for m := range methods {
+				allowed = append(allowed, m)
+			}
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
@@ -69,6 +127,51 @@ func methodHandler(method string, handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// listener is the server running on one port. It serves whichever handler
+// was mounted last.
+type listener struct {
+	handler atomic.Pointer[http.Handler]
+	scope   *reloadScope // nil unless started under a ReloadScope
+}
+
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*l.handler.Load()).ServeHTTP(w, r)
+}
+
+var (
+	listenersMu sync.Mutex
+	listeners   = map[int]*listener{}
+)
+
+// mount makes h the handler for port and reports whether a server was
+// already running there; if not, the caller must start one serving l. A
+// running server only takes h when it was started in the same non-nil
+// reload scope; otherwise the port is in use.
+func mount(port int, h http.Handler, scope *reloadScope) (l *listener, running bool, err error) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	l, running = listeners[port]
+	if running && (scope == nil || l.scope != scope) {
+		return nil, false, fmt.Errorf("listen on port %d: address already in use", port)
+	}
+	if !running {
+		l = &listener{scope: scope}
+		listeners[port] = l
+	}
+	l.handler.Store(&h)
+	return l, running, nil
+}
+
+// unmount forgets l after its server stops, so the port can be listened on
+// again.
+func unmount(port int, l *listener) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	if listeners[port] == l {
+		delete(listeners, port)
+	}
+}
+
 // --- Helpers ---
 func ParseJSON(r *http.Request, target interface{}) error {
 	defer r.Body.Close()
@@ -0,0 +1,134 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func text(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { WriteText(w, 200, body) }
+}
+
+func get(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestServerRoutes(t *testing.T) {
+	s := NewOsunServer(0)
+	s.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Order", "outer")
+			next(w, r)
+		}
+	})
+	s.Use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Order", "inner")
+			next(w, r)
+		}
+	})
+	s.Handle("get", "/users", text("list"))
+	s.Handle("POST", "/users", text("create"))
+	h := s.handler()
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/users", 200, "list"},
+		{"POST", "/users", 200, "create"},
+		{"DELETE", "/users", 405, "Method not allowed\n"},
+		{"GET", "/missing", 404, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		rec := get(h, tt.method, tt.path)
+		if rec.Code != tt.status || rec.Body.String() != tt.body {
+			t.Errorf("%s %s: %d %q, want %d %q", tt.method, tt.path, rec.Code, rec.Body, tt.status, tt.body)
+		}
+	}
+	if got := get(h, "DELETE", "/users").Header().Get("Allow"); got != "GET, POST" {
+		t.Errorf("Allow %q", got)
+	}
+	if got := get(h, "GET", "/users").Header().Values("X-Order"); len(got) != 2 || got[0] != "outer" {
+		t.Errorf("middleware ran in order %v, want [outer inner]", got)
+	}
+}
+
+// Listening on a port that is already serving swaps in the new routes.
+func TestMountReplacesRoutes(t *testing.T) {
+	const port = -1 // never passed to the network
+	defer func() {
+		listenersMu.Lock()
+		delete(listeners, port)
+		listenersMu.Unlock()
+	}()
+	scope := reloadScopeOf(ReloadScope(context.Background()))
+
+	first := NewOsunServer(port)
+	first.Handle("GET", "/home", text("v1"))
+	first.Handle("GET", "/old", text("old"))
+	l, running, err := mount(port, first.handler(), scope)
+	if err != nil || running {
+		t.Fatalf("first mount: running %v, error %v", running, err)
+	}
+
+	second := NewOsunServer(port)
+	second.Handle("GET", "/home", text("v2"))
+	l2, running, err := mount(port, second.handler(), scope)
+	if err != nil || !running || l2 != l {
+		t.Fatalf("second mount did not reuse the running server: error %v", err)
+	}
+	if body := get(l, "GET", "/home").Body.String(); body != "v2" {
+		t.Errorf("body %q, want v2", body)
+	}
+	if code := get(l, "GET", "/old").Code; code != 404 {
+		t.Errorf("removed route answered %d", code)
+	}
+}
+
+// Only a reload in the scope that started a server may replace its routes.
+func TestListenOnPortInUse(t *testing.T) {
+	const port = -2 // never passed to the network
+	defer func() {
+		listenersMu.Lock()
+		delete(listeners, port)
+		listenersMu.Unlock()
+	}()
+	ctx := ReloadScope(context.Background())
+
+	owner := NewOsunServer(port)
+	owner.Handle("GET", "/home", text("owner"))
+	l, _, err := mount(port, owner.handler(), reloadScopeOf(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"no scope":    context.Background(),
+		"other scope": ReloadScope(context.Background()),
+	} {
+		intruder := NewOsunServer(port)
+		intruder.Handle("GET", "/home", text("intruder"))
+		err := intruder.Listen(ctx)
+		if want := "listen on port -2: address already in use"; err == nil || err.Error() != want {
+			t.Errorf("%s: error %v, want %q", name, err, want)
+		}
+		if body := get(l, "GET", "/home").Body.String(); body != "owner" {
+			t.Errorf("%s: body %q, want owner", name, body)
+		}
+	}
+
+	reload := NewOsunServer(port)
+	reload.Handle("GET", "/home", text("reloaded"))
+	if err := reload.Listen(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if body := get(l, "GET", "/home").Body.String(); body != "reloaded" {
+		t.Errorf("body %q, want reloaded", body)
+	}
+}
@@ -1,6 +1,7 @@
 package runtime
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -25,6 +26,8 @@ func InitBuiltins() {
 			server := NewOsunServer(port)
 			return server
 		},
+		"writeJSON": WriteJSON,
+		"writeText": WriteText,
 	}
 
 	// Database manager
@@ -36,18 +39,22 @@ func InitBuiltins() {
 			}
 			return "connected"
 		},
-		"insert": func(table string, data map[string]interface{}) {
-			if err := Insert(table, data); err != nil {
-				fmt.Println("DB insert error:", err)
-			} else {
-				fmt.Println("✅ Inserted into", table)
+		// The interpreter passes the running script's context, so an
+		// insert is abandoned when the run or request is cancelled.
+		"insert": func(ctx context.Context, table string, data map[string]interface{}) error {
+			if err := Insert(ctx, table, data); err != nil {
+				return fmt.Errorf("db insert into %s: %w", table, err)
 			}
+			fmt.Println("✅ Inserted into", table)
+			return nil
 		},
 	}
 
 	// Auth middleware
 	symbols["auth"] = map[string]interface{}{
-		"requireAuth": RequireAuth,
+		"requireAuth":   RequireAuth,
+		"generateToken": GenerateToken,
+		"validateToken": ValidateToken,
 	}
 
 	fmt.Println("✅ Osun builtins initialized.")
@@ -57,6 +64,12 @@ func GetSymbol(name string) interface{} {
 	return symbols[name]
 }
 
+// Callable is a script-level function that Go code can invoke, such as an
+// Osun `fn` passed to a builtin as a callback.
+type Callable interface {
+	Call(args ...interface{}) (interface{}, error)
+}
+
 // -------------------- Server --------------------
 
 
@@ -87,9 +100,9 @@ func ConnectDatabase(driver, connStr string) error {
 	return nil
 }
 
-func Insert(table string, data map[string]interface{}) error {
+func Insert(ctx context.Context, table string, data map[string]interface{}) error {
 	jsonStr, _ := json.Marshal(data)
-	return DBInsert(table, string(jsonStr))
+	return DBInsert(ctx, table, string(jsonStr))
 }
 
 
@@ -7,40 +7,55 @@ import (
 	"time"
 )
 
-func WatchAndRun(targetFile string, runFunc func(string)) {
-	lastMods := make(map[string]time.Time)
-
-	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() && filepath.Ext(path) == ".os" {
-			lastMods[path] = info.ModTime()
-		}
-		return nil
-	})
+// WatchAndRun polls the .os files under the working directory and, when
+// any of them is added, edited or removed, calls runFunc with the current
+// content of targetFile and the paths that changed, so callers can drop
+// cached state for just those files.
+func WatchAndRun(targetFile string, runFunc func(code string, changed []string)) {
+	lastMods := scanOsFiles()
 
 	fmt.Println("👀 Watching all .os files...")
 
 	for {
 		time.Sleep(1 * time.Second)
-		changed := false
-
-		filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-			if !info.IsDir() && filepath.Ext(path) == ".os" {
-				if info.ModTime() != lastMods[path] {
-					changed = true
-					lastMods[path] = info.ModTime()
-				}
+		mods := scanOsFiles()
+
+		var changed []string
+		for path, mod := range mods {
+			if last, ok := lastMods[path]; !ok || !mod.Equal(last) {
+				changed = append(changed, path)
 			}
-			return nil
-		})
+		}
+		for path := range lastMods {
+			if _, ok := mods[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		lastMods = mods
 
-		if changed {
+		if len(changed) > 0 {
 			fmt.Println("♻️  Change detected, restarting Osun script...")
 			data, _ := os.ReadFile(targetFile)
-			runFunc(string(data))
+			runFunc(string(data), changed)
 		}
 	}
 }
 
+// scanOsFiles returns the modification time of every .os file under the
+// working directory.
+func scanOsFiles() map[string]time.Time {
+	mods := make(map[string]time.Time)
+	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".os" {
+			mods[path] = info.ModTime()
+		}
+		return nil
+	})
+	return mods
+}
 
 func getModTime(file string) time.Time {
 	info, err := os.Stat(file)
@@ -0,0 +1,209 @@
+package token
+
+import "fmt"
+
+// Kind identifies the lexical class of a token.
+type Kind int
+
+const (
+	ILLEGAL Kind = iota
+	EOF
+
+	// Literals
+	IDENT
+	NUMBER
+	STRING
+	TEMPLATE // `text ${expr} text`
+
+	// Operators
+	ASSIGN       // =
+	PLUS_ASSIGN  // +=
+	MINUS_ASSIGN // -=
+	STAR_ASSIGN  // *=
+	SLASH_ASSIGN // /=
+	INC          // ++
+	DEC          // --
+	PLUS         // +
+	MINUS        // -
+	STAR         // *
+	SLASH        // /
+	PERCENT      // %
+	EQ           // ==
+	NEQ          // !=
+	LT           // <
+	LTE          // <=
+	GT           // >
+	GTE          // >=
+	AND          // &&
+	OR           // ||
+	NOT          // !
+
+	// Delimiters
+	COMMA     // ,
+	DOT       // .
+	DOTDOT    // ..
+	COLON     // :
+	SEMICOLON // ;
+	LPAREN    // (
+	RPAREN    // )
+	LBRACE    // {
+	RBRACE    // }
+	LBRACKET  // [
+	RBRACKET  // ]
+
+	// Keywords
+	LET
+	CONST
+	IF
+	ELSE
+	WHILE
+	FOR
+	IN
+	BREAK
+	CONTINUE
+	FN
+	RETURN
+	TRY
+	CATCH
+	FINALLY
+	THROW
+	IMPORT
+	EXPORT
+	TRUE
+	FALSE
+	NULL
+)
+
+var kindNames = map[Kind]string{
+	ILLEGAL:      "ILLEGAL",
+	EOF:          "EOF",
+	IDENT:        "identifier",
+	NUMBER:       "number",
+	STRING:       "string",
+	TEMPLATE:     "template string",
+	ASSIGN:       "=",
+	PLUS_ASSIGN:  "+=",
+	MINUS_ASSIGN: "-=",
+	STAR_ASSIGN:  "*=",
+	SLASH_ASSIGN: "/=",
+	INC:          "++",
+	DEC:          "--",
+	PLUS:         "+",
+	MINUS:        "-",
+	STAR:         "*",
+	SLASH:        "/",
+	PERCENT:      "%",
+	EQ:           "==",
+	NEQ:          "!=",
+	LT:           "<",
+	LTE:          "<=",
+	GT:           ">",
+	GTE:          ">=",
+	AND:          "&&",
+	OR:           "||",
+	NOT:          "!",
+	COMMA:        ",",
+	DOT:          ".",
+	DOTDOT:       "..",
+	COLON:        ":",
+	SEMICOLON:    ";",
+	LPAREN:       "(",
+	RPAREN:       ")",
+	LBRACE:       "{",
+	RBRACE:       "}",
+	LBRACKET:     "[",
+	RBRACKET:     "]",
+	LET:          "let",
+	CONST:        "const",
+	IF:           "if",
+	ELSE:         "else",
+	WHILE:        "while",
+	FOR:          "for",
+	IN:           "in",
+	BREAK:        "break",
+	CONTINUE:     "continue",
+	FN:           "fn",
+	RETURN:       "return",
+	TRY:          "try",
+	CATCH:        "catch",
+	FINALLY:      "finally",
+	THROW:        "throw",
+	IMPORT:       "import",
+	EXPORT:       "export",
+	TRUE:         "true",
+	FALSE:        "false",
+	NULL:         "null",
+}
+
+func (k Kind) String() string {
+	if s, ok := kindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+var keywords = map[string]Kind{
+	"let":      LET,
+	"const":    CONST,
+	"if":       IF,
+	"else":     ELSE,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"fn":       FN,
+	"func":     FN, // accepted for older scripts
+	"return":   RETURN,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"export":   EXPORT,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+}
+
+// LookupIdent returns the keyword kind for ident, or IDENT if it is not reserved.
+func LookupIdent(ident string) Kind {
+	if k, ok := keywords[ident]; ok {
+		return k
+	}
+	return IDENT
+}
+
+// Pos is a 1-based line/column position in the source.
+type Pos struct {
+	Line int
+	Col  int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Token is a single lexical unit produced by the lexer.
+type Token struct {
+	Kind    Kind
+	Literal string
+	Pos     Pos
+	Parts   []TemplatePart // only set for TEMPLATE tokens
+}
+
+// TemplatePart is one piece of a template string: either literal text with
+// escapes already decoded, or the source of a ${...} interpolation.
+type TemplatePart struct {
+	IsExpr bool
+	Text   string // decoded text, or the raw expression source
+	Pos    Pos    // where the expression source begins
+}
+
+func (t Token) String() string {
+	switch t.Kind {
+	case IDENT, NUMBER, STRING, ILLEGAL:
+		return fmt.Sprintf("%s %q", t.Kind, t.Literal)
+	}
+	return t.Kind.String()
+}